@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/tuputech/tupu-go-sdk/recognition/audit"
+)
+
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: tupu audit verify [flags] <audit.jsonl>...")
+	}
+	switch args[0] {
+	case "verify":
+		return runAuditVerify(args[1:])
+	default:
+		return usagef("unknown audit command %q, want verify", args[0])
+	}
+}
+
+func runAuditVerify(args []string) error {
+	var (
+		fs         = flag.NewFlagSet("audit verify", flag.ContinueOnError)
+		signatures = fs.Bool("signatures", false, "verify the TUPU signatures of the results kept in the log")
+		pubPath    = fs.String("pub", "", "verify the signatures by the key file instead of the embedded TUPU public key")
+		head       = fs.String("head", "", "the published hash the last entry must have, detects truncation")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tupu audit verify [flags] <audit.jsonl>...\n\n"+
+			"Checks the hash chain of audit logs written by the audit package.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("audit verify needs at least one log file")
+	}
+	if *head != "" && fs.NArg() != 1 {
+		return usagef("-head needs exactly one log file")
+	}
+
+	var opts []audit.VerifyOptFunc
+	if *signatures {
+		verifier, err := loadVerifier(*pubPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, audit.WithSignatureCheck(verifier))
+	}
+
+	failed := 0
+	for _, path := range fs.Args() {
+		report, err := audit.VerifyFile(path, opts...)
+		if err == nil && *head != "" && report.LastHash != *head {
+			err = fmt.Errorf("%w: the last hash %q isn't the published head", audit.ErrBrokenChain, report.LastHash)
+		}
+		if err != nil {
+			failed++
+			if errors.Is(err, audit.ErrBrokenChain) {
+				fmt.Printf("%s: FAILED after %d entries: %v\n", path, report.Entries, err)
+			} else {
+				fmt.Printf("%s: FAILED: %v\n", path, err)
+			}
+			continue
+		}
+		fmt.Printf("%s: OK, %d entries, last seq %d, head %s", path, report.Entries, report.LastSeq, report.LastHash)
+		if *signatures {
+			fmt.Printf(", %d signatures verified", report.Signatures)
+		}
+		fmt.Println()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d logs failed to verify", failed, fs.NArg())
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	tupubulk "github.com/tuputech/tupu-go-sdk/lib/bulk"
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+	"github.com/tuputech/tupu-go-sdk/recognition"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textsync"
+	"github.com/tuputech/tupu-go-sdk/recognition/video/videosync"
+)
+
+// batchRunner recognizes the rows of a manifest, the handlers are created on first use
+type batchRunner struct {
+	opts *options
+
+	mu     sync.Mutex
+	image  *recognition.Handler
+	text   *textsync.SyncHandler
+	speech *speechsync.SyncHandler
+	video  *videosync.SyncHandler
+}
+
+func runBatch(args []string) error {
+	var (
+		opts = new(options)
+		fs   = newFlagSet("batch", "batch [flags] <manifest.jsonl|manifest.csv|->\n\n"+
+			"A row has the fields: id, type (image, text, speech or video), url, path, content (text only),\n"+
+			"tag, tasks, userId and forumId. The tasks of a csv row are separated by comma or semicolon.", opts)
+		format      = fs.String("format", "", "manifest format: jsonl or csv, default by the extension")
+		outPath     = fs.String("out", "-", "results file, - for stdout")
+		outFormat   = fs.String("out-format", "", "results format: jsonl or csv, default by the extension")
+		checkpoint  = fs.String("checkpoint", "", "checkpoint file, default <out>.checkpoint or <manifest>.checkpoint")
+		defaultType = fs.String("type", "", "type of the rows without type")
+		concurrency = fs.Int("concurrency", tupubulk.DefaultConcurrency, "max number of requests at the same time")
+		rate        = fs.Float64("rate", 0, "max number of requests per second, 0 means no limit")
+		retryFailed = fs.Bool("retry-failed", false, "run the failed rows in the checkpoint again")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("batch needs one manifest")
+	}
+
+	// step1. open the manifest, the results and the checkpoint
+	var (
+		inPath = fs.Arg(0)
+		in     io.Reader
+	)
+	if inPath == "-" {
+		in = os.Stdin
+	} else {
+		f, err := os.Open(inPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	reader, err := newManifestReader(in, formatOf(inPath, *format))
+	if err != nil {
+		return err
+	}
+
+	if *checkpoint == "" {
+		switch {
+		case *outPath != "-":
+			*checkpoint = *outPath + ".checkpoint"
+		case inPath != "-":
+			*checkpoint = inPath + ".checkpoint"
+		default:
+			return usagef("-checkpoint is required if both the manifest and the results are stdio")
+		}
+	}
+	done, err := loadCheckpoint(*checkpoint)
+	if err != nil {
+		return err
+	}
+	writer, err := openResultWriter(*outPath, formatOf(*outPath, *outFormat), *checkpoint)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	// step2. run the rows not done, an interruption stops reading and waits for the running rows
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		runner   = &batchRunner{opts: opts}
+		executor = tupubulk.NewExecutor(tupubulk.WithConcurrency(*concurrency), tupubulk.WithRate(*rate))
+		ok       int64
+		failed   int64
+		skipped  int
+		writeErr atomic.Value
+		readErr  error
+	)
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+		if status, ok := done[checkpointKey(row.ID, row.Line)]; ok && !(*retryFailed && status == statusFailed) {
+			skipped++
+			continue
+		}
+		if row.Type == "" {
+			row.Type = *defaultType
+		}
+
+		err = executor.Go(ctx, func(ctx context.Context) {
+			res := runner.run(row)
+			if res.Status == statusOK {
+				atomic.AddInt64(&ok, 1)
+			} else {
+				atomic.AddInt64(&failed, 1)
+			}
+			if err := writer.Write(res); err != nil {
+				writeErr.Store(err)
+			}
+		})
+		if err != nil || writeErr.Load() != nil {
+			break
+		}
+	}
+	executor.Wait()
+
+	// step3. report the summary
+	fmt.Fprintf(os.Stderr, "tupu batch: %d ok, %d failed, %d skipped by checkpoint %s\n", ok, failed, skipped, *checkpoint)
+	switch {
+	case writeErr.Load() != nil:
+		return writeErr.Load().(error)
+	case readErr != nil:
+		return readErr
+	case ctx.Err() != nil:
+		return fmt.Errorf("interrupted, run the same command to resume")
+	case failed > 0:
+		return fmt.Errorf("%d rows failed", failed)
+	}
+	return nil
+}
+
+// run recognizes a row, the row is failed on error, non-2xx status code or non-zero code of TUPU
+func (r *batchRunner) run(row *manifestRow) *rowResult {
+	res := &rowResult{Line: row.Line, ID: row.ID, Type: row.Type, Status: statusFailed}
+
+	result, statusCode, err := r.perform(row)
+	res.StatusCode = statusCode
+	if result != "" {
+		if json.Valid([]byte(result)) {
+			res.Result = json.RawMessage(result)
+		} else {
+			res.Result, _ = json.Marshal(result)
+		}
+	}
+	switch {
+	case err != nil:
+		res.Error = err.Error()
+	case statusCode > 299:
+		res.Error = fmt.Sprintf("status code: %d", statusCode)
+	default:
+		if err = resultCodeError(result); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Status = statusOK
+		}
+	}
+	return res
+}
+
+// resultCodeError returns an error of the code and message of a result, nil if the code is 0
+func resultCodeError(result string) error {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return fmt.Errorf("invalid result: %v", err)
+	}
+	r, err := tupumodel.ParseResultData(data, nil)
+	if err != nil {
+		return err
+	}
+	if r.Code != 0 {
+		return fmt.Errorf("code: %d, message: %s", r.Code, r.Message)
+	}
+	return nil
+}
+
+func (r *batchRunner) perform(row *manifestRow) (result string, statusCode int, err error) {
+	if row.URL == "" && row.Path == "" && !(row.Type == "text" && row.Content != "") {
+		return "", 0, fmt.Errorf("row has no url, path or content")
+	}
+
+	switch row.Type {
+	case "image":
+		hdler, err := r.imageHandler()
+		if err != nil {
+			return "", 0, err
+		}
+		img := recognition.NewRemoteImage(row.URL)
+		if row.URL == "" {
+			img = recognition.NewLocalImage(row.Path)
+		}
+		var tags []string
+		if row.Tag != "" {
+			tags = []string{row.Tag}
+		}
+		return hdler.Perform(r.opts.secretID, []*recognition.Image{img}, tags, r.tasksOf(row))
+	case "text":
+		hdler, err := r.textHandler()
+		if err != nil {
+			return "", 0, err
+		}
+		content := row.Content
+		if content == "" {
+			if row.URL != "" {
+				return "", 0, fmt.Errorf("text row needs content or path")
+			}
+			buf, err := ioutil.ReadFile(row.Path)
+			if err != nil {
+				return "", 0, err
+			}
+			content = string(buf)
+		}
+		return hdler.Perform(r.opts.secretID, []textsync.TextAsyncItem{{
+			Content:   content,
+			ContentID: row.ID,
+			UserID:    row.UserID,
+			ForumID:   row.ForumID,
+		}})
+	case "speech":
+		hdler, err := r.speechHandler()
+		if err != nil {
+			return "", 0, err
+		}
+		if row.URL != "" {
+			return hdler.PerformWithURL(r.opts.secretID, []string{row.URL}, r.tasksOf(row)...)
+		}
+		return hdler.PerformWithPath(r.opts.secretID, []string{row.Path}, r.tasksOf(row)...)
+	case "video":
+		hdler, err := r.videoHandler()
+		if err != nil {
+			return "", 0, err
+		}
+		var syncOpts []videosync.SyncOptFunc
+		if row.Tag != "" {
+			syncOpts = append(syncOpts, videosync.WithTag(row.Tag))
+		}
+		if tasks := r.tasksOf(row); len(tasks) > 0 {
+			syncOpts = append(syncOpts, videosync.WithTask(tasks...))
+		}
+		if row.URL != "" {
+			return hdler.PerformWithURL(r.opts.secretID, []string{row.URL}, syncOpts...)
+		}
+		return hdler.PerformWithPath(r.opts.secretID, []string{row.Path}, syncOpts...)
+	default:
+		return "", 0, fmt.Errorf("unknown type %q, want image, text, speech or video", row.Type)
+	}
+}
+
+// tasksOf returns the tasks of the row, or the tasks of the -tasks flag
+func (r *batchRunner) tasksOf(row *manifestRow) []string {
+	if len(row.Tasks) > 0 {
+		return row.Tasks
+	}
+	return r.opts.taskList()
+}
+
+func (r *batchRunner) imageHandler() (*recognition.Handler, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.image != nil {
+		return r.image, nil
+	}
+
+	var err error
+	if r.opts.serverURL != "" {
+		r.image, err = recognition.NewHandlerWithURL(r.opts.privateKey, r.opts.serverURL)
+	} else {
+		r.image, err = recognition.NewHandler(r.opts.privateKey)
+	}
+	if err != nil {
+		r.image = nil
+	}
+	return r.image, err
+}
+
+func (r *batchRunner) textHandler() (*textsync.SyncHandler, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.text == nil {
+		hdler, err := textsync.NewTextHandler(r.opts.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		r.opts.configure(hdler)
+		r.text = hdler
+	}
+	return r.text, nil
+}
+
+func (r *batchRunner) speechHandler() (*speechsync.SyncHandler, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.speech == nil {
+		hdler, err := speechsync.NewSyncHandler(r.opts.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		r.opts.configure(hdler)
+		r.speech = hdler
+	}
+	return r.speech, nil
+}
+
+func (r *batchRunner) videoHandler() (*videosync.SyncHandler, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.video == nil {
+		hdler, err := videosync.NewSyncHandler(r.opts.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		r.opts.configure(hdler)
+		r.video = hdler
+	}
+	return r.video, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	exitFailure = 1
+	exitUsage   = 2
+
+	envSecretID   = "TUPU_SECRET_ID"
+	envPrivateKey = "TUPU_PRIVATE_KEY"
+	envProfile    = "TUPU_PROFILE"
+	envConfig     = "TUPU_CONFIG"
+
+	defaultProfile = "default"
+)
+
+type (
+	// usageError is an error of the command line, the exit code is exitUsage
+	usageError string
+
+	// profile is a set of credentials in the config file
+	profile struct {
+		SecretID   string `json:"secretId"`
+		PrivateKey string `json:"privateKey"`
+		ServerURL  string `json:"serverUrl,omitempty"`
+	}
+
+	// options is the flags shared by all commands
+	options struct {
+		secretID   string
+		privateKey string
+		profile    string
+		serverURL  string
+		timeout    int
+		output     string
+		tasks      string
+	}
+)
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError(fmt.Sprintf(format, args...))
+}
+
+// newFlagSet returns a flag set with the shared flags registered to opts
+func newFlagSet(name, usage string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.secretID, "secret-id", "", "secret id, default $"+envSecretID+" or the profile")
+	fs.StringVar(&opts.privateKey, "key", "", "path of the rsa private key, default $"+envPrivateKey+" or the profile")
+	fs.StringVar(&opts.profile, "profile", "", "profile in the config file, default $"+envProfile+" or \""+defaultProfile+"\"")
+	fs.StringVar(&opts.serverURL, "server", "", "server url, default the url of the api")
+	fs.IntVar(&opts.timeout, "timeout", 0, "request timeout in seconds")
+	fs.StringVar(&opts.output, "o", "pretty", "output format: raw or pretty")
+	fs.StringVar(&opts.tasks, "tasks", "", "comma separated task ids, default the tasks of the secret id")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tupu %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, and the credentials are resolved from flags, environment and profile
+func parseFlags(fs *flag.FlagSet, opts *options, args []string) error {
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if opts.output != "raw" && opts.output != "pretty" {
+		return usagef("unknown output format %q", opts.output)
+	}
+	return opts.resolve()
+}
+
+// parseArgs parses args, -h prints the usage and exits
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		return usageError(err.Error())
+	}
+	return nil
+}
+
+// resolve fills the credentials not given by flags from the environment and the profile
+func (opts *options) resolve() error {
+	if opts.secretID == "" {
+		opts.secretID = os.Getenv(envSecretID)
+	}
+	if opts.privateKey == "" {
+		opts.privateKey = os.Getenv(envPrivateKey)
+	}
+	if opts.secretID != "" && opts.privateKey != "" && opts.serverURL != "" {
+		return nil
+	}
+
+	prof, err := loadProfile(opts.profile)
+	if err != nil {
+		return err
+	}
+	if prof != nil {
+		if opts.secretID == "" {
+			opts.secretID = prof.SecretID
+		}
+		if opts.privateKey == "" {
+			opts.privateKey = prof.PrivateKey
+		}
+		if opts.serverURL == "" {
+			opts.serverURL = prof.ServerURL
+		}
+	}
+
+	if opts.secretID == "" || opts.privateKey == "" {
+		return usagef("secret id and private key are required, see -secret-id and -key")
+	}
+	return nil
+}
+
+// loadProfile returns the named profile in the config file, nil if the default config file
+// doesn't exist. The config file is a json object of profiles, such as
+//
+//	{"default": {"secretId": "...", "privateKey": "/path/to/rsa_private_key.pem"}}
+func loadProfile(name string) (*profile, error) {
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	explicit := name != ""
+	if name == "" {
+		name = defaultProfile
+	}
+
+	path := os.Getenv(envConfig)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, ".tupu", "config.json")
+	} else {
+		explicit = true
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles map[string]*profile
+	if err = json.Unmarshal(buf, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	prof, ok := profiles[name]
+	if !ok {
+		if explicit {
+			return nil, fmt.Errorf("profile %q not found in %s", name, path)
+		}
+		return nil, nil
+	}
+	// the key path is relative to the config file
+	if prof.PrivateKey != "" && !filepath.IsAbs(prof.PrivateKey) {
+		prof.PrivateKey = filepath.Join(filepath.Dir(path), prof.PrivateKey)
+	}
+	return prof, nil
+}
+
+// taskList returns the task ids of the -tasks flag
+func (opts *options) taskList() []string {
+	var tasks []string
+	for _, task := range strings.Split(opts.tasks, ",") {
+		if task = strings.TrimSpace(task); task != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// handlerConf is implemented by all handlers of the SDK
+type handlerConf interface {
+	SetServerURL(url string)
+	SetTimeout(timeout int)
+}
+
+// configure applies the shared flags to the handler
+func (opts *options) configure(h handlerConf) {
+	if opts.serverURL != "" {
+		h.SetServerURL(opts.serverURL)
+	}
+	h.SetTimeout(opts.timeout)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tuputech/tupu-go-sdk/recognition"
+)
+
+func runImage(args []string) error {
+	var (
+		opts = new(options)
+		fs   = newFlagSet("image", "image [flags] <url|path|->...", opts)
+		tags = fs.String("tags", "", "comma separated tags, one for each image in order")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	// step1. create image handler, -timeout is not supported by the image handler
+	var hdler *recognition.Handler
+	if opts.serverURL != "" {
+		hdler, err = recognition.NewHandlerWithURL(opts.privateKey, opts.serverURL)
+	} else {
+		hdler, err = recognition.NewHandler(opts.privateKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	// step2. wrap the inputs as images
+	images := make([]*recognition.Image, 0, len(inputs))
+	for _, in := range inputs {
+		var img *recognition.Image
+		switch {
+		case in.url != "":
+			img = recognition.NewRemoteImage(in.url)
+		case in.path != "":
+			img = recognition.NewLocalImage(in.path)
+		default:
+			img = recognition.NewBinaryImage(in.data, in.name)
+		}
+		if img == nil {
+			return fmt.Errorf("invalid image: %s%s", in.url, in.path)
+		}
+		images = append(images, img)
+	}
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+
+	// step3. get recognition result
+	result, statusCode, err := hdler.Perform(opts.secretID, images, tagList, opts.taskList())
+	return printResult(opts, result, statusCode, err, func(s string) (interface{}, error) {
+		if r := recognition.ParseResult(s); r != nil {
+			return r, nil
+		}
+		return nil, fmt.Errorf("invalid image result")
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// stdinName is the file name of the data read from stdin
+const stdinName = "stdin"
+
+// input is a file to recognize, one of url, path and data is set
+type input struct {
+	url  string
+	path string
+	name string
+	data []byte
+}
+
+// readInputs classifies args to urls and paths, "-" is the data of stdin
+func readInputs(args []string) ([]*input, error) {
+	if len(args) == 0 {
+		return nil, usagef("no input, give urls, paths or - for stdin")
+	}
+
+	inputs := make([]*input, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case arg == "-":
+			data, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, &input{name: stdinName, data: data})
+		case isURL(arg):
+			inputs = append(inputs, &input{url: arg})
+		default:
+			if _, err := os.Stat(arg); err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, &input{path: arg})
+		}
+	}
+	return inputs, nil
+}
+
+// splitInputs returns the urls, paths and binary data of inputs
+func splitInputs(inputs []*input) (urls, paths []string, binary map[string][]byte) {
+	for _, in := range inputs {
+		switch {
+		case in.url != "":
+			urls = append(urls, in.url)
+		case in.path != "":
+			paths = append(paths, in.path)
+		default:
+			if binary == nil {
+				binary = make(map[string][]byte)
+			}
+			binary[in.name] = in.data
+		}
+	}
+	return
+}
+
+// readLines returns args, or the non-empty lines of stdin if args is empty or "-"
+func readLines(args []string) ([]string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return args, nil
+	}
+
+	var (
+		lines   []string
+		scanner = bufio.NewScanner(os.Stdin)
+	)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// printResult writes the result to stdout, the typed result of parse is pretty-printed unless
+// the output is raw. A non-2xx status code is an error
+func printResult(opts *options, result string, statusCode int, err error, parse func(string) (interface{}, error)) error {
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "raw" || parse == nil {
+		fmt.Println(result)
+	} else if typed, perr := parse(result); perr == nil && typed != nil {
+		out, _ := json.MarshalIndent(typed, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		// not the expected schema, indent the raw json
+		var out bytes.Buffer
+		if json.Indent(&out, []byte(result), "", "  ") != nil {
+			fmt.Println(result)
+		} else {
+			fmt.Println(out.String())
+		}
+	}
+
+	if statusCode > 299 {
+		return fmt.Errorf("status code: %d", statusCode)
+	}
+	return nil
+}
+
+// printTyped writes a result built by the SDK, it's indented unless the output is raw
+func printTyped(opts *options, v interface{}, statusCode int) error {
+	var out []byte
+	if opts.output == "raw" {
+		out, _ = json.Marshal(v)
+	} else {
+		out, _ = json.MarshalIndent(v, "", "  ")
+	}
+	fmt.Println(string(out))
+
+	if statusCode > 299 {
+		return fmt.Errorf("status code: %d", statusCode)
+	}
+	return nil
+}
+
+// parseRaw pretty-prints the result as is
+func parseRaw(s string) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal([]byte(s), &v)
+	return v, err
+}
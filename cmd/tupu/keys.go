@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
+)
+
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: tupu keys <generate|inspect|sign|verify> [flags] ...")
+	}
+	switch args[0] {
+	case "generate":
+		return runKeysGenerate(args[1:])
+	case "inspect":
+		return runKeysInspect(args[1:])
+	case "sign":
+		return runKeysSign(args[1:])
+	case "verify":
+		return runKeysVerify(args[1:])
+	default:
+		return usagef("unknown keys command %q, want generate, inspect, sign or verify", args[0])
+	}
+}
+
+func newKeysFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tupu keys %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runKeysGenerate(args []string) error {
+	var (
+		fs      = newKeysFlagSet("keys generate", "generate [flags]\n\nThe public key is uploaded to the TUPU console, keep the private key secret.")
+		bits    = fs.Int("bits", tuputools.DefaultKeyBits, "key size in bits")
+		format  = fs.String("format", tuputools.FormatPKCS1, "private key format: pkcs1 or pkcs8")
+		outPath = fs.String("out", "rsa_private_key.pem", "private key file")
+		pubPath = fs.String("pub", "rsa_public_key.pem", "public key file")
+		force   = fs.Bool("force", false, "overwrite the existing files")
+	)
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usagef("keys generate takes no argument")
+	}
+	if !*force {
+		for _, path := range []string{*outPath, *pubPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s exists, use -force to overwrite", path)
+			}
+		}
+	}
+
+	privatePEM, publicPEM, err := tuputools.GenerateKeyPair(*bits, *format)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(*outPath, privatePEM, 0600); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(*pubPath, publicPEM, 0644); err != nil {
+		return err
+	}
+
+	info, err := tuputools.InspectKey(publicPEM)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("private key: %s (%s, %d bits)\n", *outPath, *format, info.Bits)
+	fmt.Printf("public key:  %s\n", *pubPath)
+	fmt.Printf("fingerprint: %s\n             %s\n", tuputools.Fingerprint(info.PublicKey), tuputools.FingerprintMD5(info.PublicKey))
+	fmt.Println("upload the public key to the TUPU console")
+	return nil
+}
+
+func runKeysInspect(args []string) error {
+	var (
+		fs     = newKeysFlagSet("keys inspect", "inspect [flags] <key.pem|->")
+		tupu   = fs.Bool("tupu", false, "inspect the embedded TUPU public key instead")
+		pubOut = fs.Bool("pub", false, "print the public key in PEM to upload")
+	)
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	var (
+		pemBytes []byte
+		err      error
+	)
+	switch {
+	case *tupu:
+		pemBytes = []byte(tuputools.TupuPublicKeyPEM)
+	case fs.NArg() != 1:
+		return usagef("keys inspect needs one key file or -tupu")
+	case fs.Arg(0) == "-":
+		pemBytes, err = ioutil.ReadAll(os.Stdin)
+	default:
+		pemBytes, err = ioutil.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := tuputools.InspectKey(pemBytes)
+	if err != nil {
+		return err
+	}
+	kind := "public"
+	if info.Private {
+		kind = "private"
+	}
+	fmt.Printf("type:        %s key\n", kind)
+	fmt.Printf("format:      %s\n", info.Format)
+	fmt.Printf("bits:        %d\n", info.Bits)
+	fmt.Printf("fingerprint: %s\n             %s\n", tuputools.Fingerprint(info.PublicKey), tuputools.FingerprintMD5(info.PublicKey))
+	if info.Bits < tuputools.DefaultKeyBits && !*tupu {
+		fmt.Printf("warning:     keys shorter than %d bits are weak\n", tuputools.DefaultKeyBits)
+	}
+	if *pubOut {
+		publicPEM, err := tuputools.EncodePublicKey(info.PublicKey, tuputools.FormatPKIX)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(publicPEM))
+	}
+	return nil
+}
+
+func runKeysSign(args []string) error {
+	var (
+		opts      = new(options)
+		fs        = newKeysFlagSet("keys sign", "sign [flags]\n\nSigns \"secretId,timestamp,nonce\" as the SDK does, to compare with a failed request.")
+		timestamp = fs.String("timestamp", "", "unix timestamp in seconds, default now")
+		nonce     = fs.String("nonce", "", "nonce, default random")
+		message   = fs.String("message", "", "sign the message instead of \"secretId,timestamp,nonce\"")
+	)
+	fs.StringVar(&opts.secretID, "secret-id", "", "secret id, default $"+envSecretID+" or the profile")
+	fs.StringVar(&opts.privateKey, "key", "", "path of the rsa private key, default $"+envPrivateKey+" or the profile")
+	fs.StringVar(&opts.profile, "profile", "", "profile in the config file, default $"+envProfile+" or \""+defaultProfile+"\"")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if err := opts.resolve(); err != nil {
+		return err
+	}
+
+	signer, err := tuputools.LoadPrivateKey(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	if *timestamp == "" {
+		*timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	if *nonce == "" {
+		*nonce = strconv.FormatInt(int64(rand.New(rand.NewSource(time.Now().UnixNano())).Uint32()), 10)
+	}
+	if *message == "" {
+		*message = tuputools.SignatureMessage(opts.secretID, *timestamp, *nonce)
+	}
+
+	signature, err := tuputools.SignString(signer, *message)
+	if err != nil {
+		return err
+	}
+	params, _ := json.MarshalIndent(map[string]string{
+		"timestamp": *timestamp,
+		"nonce":     *nonce,
+		"signature": signature,
+	}, "", "  ")
+	fmt.Printf("message:   %s\nsignature: %s\nparams:\n%s\n", *message, signature, params)
+	return nil
+}
+
+func runKeysVerify(args []string) error {
+	var (
+		fs = newKeysFlagSet("keys verify", "verify [flags] <response.json|->\n\n"+
+			"The input is a raw response {\"json\": \"...\", \"signature\": \"...\"}, or the signed message with -signature.")
+		signature = fs.String("signature", "", "signature in base64, default the signature in the response")
+		pubPath   = fs.String("pub", "", "verify by the key file instead of the embedded TUPU public key")
+	)
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("keys verify needs one input file")
+	}
+
+	var (
+		input []byte
+		err   error
+	)
+	if fs.Arg(0) == "-" {
+		input, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		input, err = ioutil.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	// step1. get the message and the signature
+	var (
+		message  = string(input)
+		response struct {
+			JSON      *string `json:"json"`
+			Signature string  `json:"signature"`
+		}
+	)
+	if json.Unmarshal(input, &response) == nil && response.JSON != nil {
+		message = *response.JSON
+		if *signature == "" {
+			*signature = response.Signature
+		}
+	} else {
+		// a message file usually ends with a newline the signer didn't sign
+		message = strings.TrimRight(message, "\r\n")
+	}
+	if *signature == "" {
+		return usagef("no signature, the input isn't a raw response and -signature is empty")
+	}
+
+	// step2. verify by the public key
+	verifier, err := loadVerifier(*pubPath)
+	if err != nil {
+		return err
+	}
+	if err = tuputools.VerifyString(verifier, message, *signature); err != nil {
+		return err
+	}
+	fmt.Println("signature OK")
+	return nil
+}
+
+// loadVerifier returns the verifier of the key file, the embedded TUPU public key if path is empty
+func loadVerifier(path string) (tuputools.Verifier, error) {
+	if path == "" {
+		return tuputools.LoadTupuPublicKey()
+	}
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := tuputools.InspectKey(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	return info.Verifier(), nil
+}
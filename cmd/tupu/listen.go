@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
+)
+
+// forwardTimeout is the timeout of forwarding a callback
+const forwardTimeout = 10 * time.Second
+
+type (
+	// callbackEvent is a received callback written to the log
+	callbackEvent struct {
+		Time     time.Time       `json:"time"`
+		Remote   string          `json:"remote"`
+		Path     string          `json:"path"`
+		Verified bool            `json:"verified"`
+		Error    string          `json:"error,omitempty"`
+		Result   json.RawMessage `json:"result,omitempty"`
+		// Body is the request body if the result can't be parsed
+		Body string `json:"body,omitempty"`
+	}
+
+	// listener verifies, prints, logs and forwards the callbacks
+	listener struct {
+		receiver *tupucallback.Receiver
+		output   string
+		quiet    bool
+		insecure bool
+		forward  string
+		client   *http.Client
+
+		mu  sync.Mutex
+		log io.Writer
+	}
+)
+
+func runListen(args []string) error {
+	var (
+		fs       = newKeysFlagSet("listen", "")
+		addr     = fs.String("addr", ":8080", "listen address")
+		logPath  = fs.String("log", "", "append the callbacks to the jsonl file")
+		forward  = fs.String("forward", "", "forward the callback body to the url")
+		insecure = fs.Bool("insecure", false, "accept the callbacks failed verification, they are still marked unverified")
+		output   = fs.String("o", "pretty", "output format: raw or pretty")
+		quiet    = fs.Bool("quiet", false, "don't print the callbacks")
+	)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tupu listen [flags]\n\n"+
+			"Starts a http server to receive the callbacks of TUPU async and stream recognition.\n"+
+			"Every path is accepted, expose it by a tunnel if TUPU can't reach this host.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *output != "raw" && *output != "pretty" {
+		return usagef("unknown output format %q", *output)
+	}
+
+	// step1. create the receiver, the results are handled by the listener
+	receiver, err := tupucallback.NewReceiver(func(string) error { return nil })
+	if err != nil {
+		return err
+	}
+	receiver.AllowUnsigned = *insecure
+	l := &listener{
+		receiver: receiver,
+		output:   *output,
+		quiet:    *quiet,
+		insecure: *insecure,
+		forward:  *forward,
+		client:   &http.Client{Timeout: forwardTimeout},
+	}
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		l.log = f
+	}
+
+	// step2. serve until interrupted
+	var (
+		server     = &http.Server{Addr: *addr, Handler: l}
+		ctx, stop  = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		serveError = make(chan error, 1)
+	)
+	defer stop()
+	go func() {
+		serveError <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "tupu listen: receiving callbacks on %s\n", *addr)
+
+	select {
+	case err = <-serveError:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// ServeHTTP implements http.Handler, a callback failed verification is answered with 400 unless insecure
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, l.receiver.MaxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// step1. verify the signature
+	event := &callbackEvent{
+		Time:   time.Now(),
+		Remote: r.RemoteAddr,
+		Path:   r.URL.Path,
+	}
+	result, err := l.receiver.Parse(body)
+	if err == nil {
+		// an unsigned body is accepted by Parse only if insecure
+		var signed struct {
+			JSON *string `json:"json"`
+		}
+		event.Verified = json.Unmarshal(body, &signed) == nil && signed.JSON != nil
+	} else {
+		event.Error = err.Error()
+	}
+	if result != "" && json.Valid([]byte(result)) {
+		event.Result = json.RawMessage(result)
+	} else {
+		event.Body = string(body)
+	}
+
+	// step2. print, log and forward
+	l.record(event)
+	if l.forward != "" {
+		go l.forwardBody(body, r.Header.Get("Content-Type"))
+	}
+
+	if err != nil && !l.insecure {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (l *listener) record(event *callbackEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.log != nil {
+		line, _ := json.Marshal(event)
+		if _, err := l.log.Write(append(line, '\n')); err != nil {
+			fmt.Fprintf(os.Stderr, "tupu listen: write log: %v\n", err)
+		}
+	}
+	if l.quiet {
+		return
+	}
+
+	status := "verified"
+	if !event.Verified {
+		status = "UNVERIFIED"
+	}
+	if event.Error != "" {
+		status += ": " + event.Error
+	}
+	fmt.Printf("[%s] POST %s from %s, %s\n", event.Time.Format(time.RFC3339), event.Path, event.Remote, status)
+
+	content := []byte(event.Body)
+	if event.Result != nil {
+		content = event.Result
+	}
+	var out bytes.Buffer
+	if l.output == "pretty" && json.Indent(&out, content, "", "  ") == nil {
+		fmt.Println(out.String())
+	} else {
+		fmt.Println(string(content))
+	}
+}
+
+// forwardBody posts the original body to the forward url, so the signature can be verified again
+func (l *listener) forwardBody(body []byte, contentType string) {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	resp, err := l.client.Post(l.forward, contentType, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tupu listen: forward: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode > 299 {
+		fmt.Fprintf(os.Stderr, "tupu listen: forward: status code: %d\n", resp.StatusCode)
+	}
+}
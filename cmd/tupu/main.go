@@ -0,0 +1,71 @@
+// Command tupu provide ad-hoc recognition of images, texts, speeches and videos by TUPU services
+//
+// Usage:
+//
+//	tupu <command> [flags] [inputs...]
+//
+// The credentials are read from the flags, the environment variables TUPU_SECRET_ID and
+// TUPU_PRIVATE_KEY, or a profile in ~/.tupu/config.json, in that order
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]*command{
+	"audit":  {summary: "verify the hash chain of audit logs", run: runAudit},
+	"batch":  {summary: "recognize the rows of a jsonl or csv manifest, resumable", run: runBatch},
+	"image":  {summary: "recognize images by url, path or stdin", run: runImage},
+	"text":   {summary: "recognize texts by arguments or stdin lines", run: runText},
+	"keys":   {summary: "manage rsa keys: generate, inspect, sign, verify", run: runKeys},
+	"listen": {summary: "receive, verify and log callbacks for development", run: runListen},
+	"speech": {summary: "recognize speeches: sync, async, stream", run: runSpeech},
+	"video":  {summary: "recognize videos: sync, async, result, close, rate", run: runVideo},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "tupu: unknown command %q\n", name)
+		usage()
+		os.Exit(exitUsage)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "tupu %s: %v\n", name, err)
+		if _, ok := err.(usageError); ok {
+			os.Exit(exitUsage)
+		}
+		os.Exit(exitFailure)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: tupu <command> [flags] [inputs...]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'tupu <command> -h' for the flags of a command.")
+}
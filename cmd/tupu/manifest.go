@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+// csvColumns is the columns of the csv results
+var csvColumns = []string{"line", "id", "type", "status", "statusCode", "error", "result"}
+
+type (
+	// manifestRow is an item to recognize in the manifest
+	manifestRow struct {
+		// Line is the line of the row in a jsonl manifest, or the record number in a csv manifest
+		Line    int      `json:"-"`
+		ID      string   `json:"id"`
+		Type    string   `json:"type"`
+		URL     string   `json:"url"`
+		Path    string   `json:"path"`
+		Content string   `json:"content"`
+		Tag     string   `json:"tag"`
+		Tasks   []string `json:"tasks"`
+		UserID  string   `json:"userId"`
+		ForumID string   `json:"forumId"`
+	}
+
+	// rowResult is the result of a row written to the output
+	rowResult struct {
+		Line       int             `json:"line"`
+		ID         string          `json:"id,omitempty"`
+		Type       string          `json:"type"`
+		Status     string          `json:"status"`
+		StatusCode int             `json:"statusCode,omitempty"`
+		Error      string          `json:"error,omitempty"`
+		Result     json.RawMessage `json:"result,omitempty"`
+	}
+
+	// manifestReader reads the rows of a manifest one by one, io.EOF is returned at the end
+	manifestReader interface {
+		Next() (*manifestRow, error)
+	}
+
+	jsonlReader struct {
+		scanner *bufio.Scanner
+		line    int
+	}
+
+	csvReader struct {
+		reader  *csv.Reader
+		columns map[string]int
+		record  int
+	}
+
+	// resultWriter appends results and checkpoints, it's safe for concurrent use
+	resultWriter struct {
+		mu         sync.Mutex
+		format     string
+		out        *os.File
+		csv        *csv.Writer
+		checkpoint *os.File
+	}
+)
+
+// formatOf returns the format by the extension of path, jsonl by default
+func formatOf(path, format string) string {
+	if format != "" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return formatCSV
+	}
+	return formatJSONL
+}
+
+func newManifestReader(r io.Reader, format string) (manifestReader, error) {
+	switch format {
+	case formatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		return &jsonlReader{scanner: scanner}, nil
+	case formatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv header: %v", err)
+		}
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		return &csvReader{reader: reader, columns: columns}, nil
+	default:
+		return nil, usagef("unknown manifest format %q, want jsonl or csv", format)
+	}
+}
+
+// Next returns the next row, blank lines and lines starting with # are skipped
+func (r *jsonlReader) Next() (*manifestRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		row := new(manifestRow)
+		if err := json.Unmarshal([]byte(text), row); err != nil {
+			return nil, fmt.Errorf("line %d: %v", r.line, err)
+		}
+		row.Line = r.line
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Next returns the next row, the tasks column is separated by comma or semicolon
+func (r *csvReader) Next() (*manifestRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.record++
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return &manifestRow{
+		Line:    r.record,
+		ID:      field("id"),
+		Type:    field("type"),
+		URL:     field("url"),
+		Path:    field("path"),
+		Content: field("content"),
+		Tag:     field("tag"),
+		Tasks: strings.FieldsFunc(field("tasks"), func(c rune) bool {
+			return c == ',' || c == ';'
+		}),
+		UserID:  field("userid"),
+		ForumID: field("forumid"),
+	}, nil
+}
+
+// checkpointKey returns the key of a row in the checkpoint, the id of the row or its line if the id is empty,
+// so the checkpoint still matches after rows are inserted into or removed from the manifest
+func checkpointKey(id string, line int) string {
+	if id != "" {
+		return strconv.Quote(id)
+	}
+	return strconv.Itoa(line)
+}
+
+// loadCheckpoint returns the status of the done rows by checkpointKey, the file is a line of
+// "<key> <status>" for every done row
+func loadCheckpoint(path string) (map[string]string, error) {
+	done := make(map[string]string)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		sep := strings.LastIndexByte(text, ' ')
+		if sep <= 0 {
+			// a partial line written on interruption
+			continue
+		}
+		key, status := text[:sep], text[sep+1:]
+		if id, err := strconv.Unquote(key); err == nil {
+			done[checkpointKey(id, 0)] = status
+		} else if line, err := strconv.Atoi(key); err == nil {
+			done[checkpointKey("", line)] = status
+		}
+	}
+	return done, scanner.Err()
+}
+
+// openResultWriter opens the output and the checkpoint for appending, the csv header is
+// written if the output is empty
+func openResultWriter(outPath, format, checkpointPath string) (*resultWriter, error) {
+	if format != formatJSONL && format != formatCSV {
+		return nil, usagef("unknown output format %q, want jsonl or csv", format)
+	}
+
+	w := &resultWriter{format: format, out: os.Stdout}
+	if outPath != "" && outPath != "-" {
+		out, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w.out = out
+	}
+	checkpoint, err := os.OpenFile(checkpointPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	w.checkpoint = checkpoint
+
+	if format == formatCSV {
+		w.csv = csv.NewWriter(w.out)
+		if info, err := w.out.Stat(); err != nil || info.Size() == 0 || w.out == os.Stdout {
+			w.csv.Write(csvColumns)
+			w.csv.Flush()
+		}
+	}
+	return w, nil
+}
+
+// Write appends the result and then marks the row done in the checkpoint, so a row is
+// written again rather than lost if the process is killed between the two writes
+func (w *resultWriter) Write(res *rowResult) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.format == formatCSV {
+		w.csv.Write([]string{
+			strconv.Itoa(res.Line), res.ID, res.Type, res.Status,
+			strconv.Itoa(res.StatusCode), res.Error, string(res.Result),
+		})
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	} else {
+		buf, _ := json.Marshal(res)
+		if _, err := w.out.Write(append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w.checkpoint, "%s %s\n", checkpointKey(res.ID, res.Line), res.Status)
+	return err
+}
+
+// Close closes the output and the checkpoint
+func (w *resultWriter) Close() error {
+	var err error
+	if w.out != nil && w.out != os.Stdout {
+		err = w.out.Close()
+	}
+	if w.checkpoint != nil {
+		if e := w.checkpoint.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechasync"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechstream"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
+)
+
+func runSpeech(args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: tupu speech <sync|async|stream> [flags] ...")
+	}
+	switch args[0] {
+	case "sync":
+		return runSpeechSync(args[1:])
+	case "async":
+		return runSpeechAsync(args[1:])
+	case "stream":
+		return runSpeechStream(args[1:])
+	default:
+		return usagef("unknown speech command %q, want sync, async or stream", args[0])
+	}
+}
+
+func runSpeechSync(args []string) error {
+	var (
+		opts = new(options)
+		fs   = newFlagSet("speech sync", "speech sync [flags] <url|path|->...", opts)
+		long = fs.Bool("long", false, "split a long wav file into segments and print the stitched timeline")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	hdler, err := speechsync.NewSyncHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	if *long {
+		if len(inputs) != 1 || inputs[0].url != "" {
+			return usagef("-long needs one wav file or stdin")
+		}
+		in := inputs[0]
+		if in.path != "" {
+			if in.data, err = ioutil.ReadFile(in.path); err != nil {
+				return err
+			}
+			in.name = filepath.Base(in.path)
+		}
+		timeline, err := hdler.PerformLongAudio(context.Background(), opts.secretID, in.name, in.data, opts.taskList())
+		if timeline == nil {
+			return err
+		}
+		if perr := printTyped(opts, timeline, 0); perr != nil {
+			return perr
+		}
+		return err
+	}
+
+	var (
+		urls, paths, binary = splitInputs(inputs)
+		result              string
+		statusCode          int
+	)
+	switch {
+	case len(urls) == len(inputs):
+		result, statusCode, err = hdler.PerformWithURL(opts.secretID, urls, opts.taskList()...)
+	case len(paths) == len(inputs):
+		result, statusCode, err = hdler.PerformWithPath(opts.secretID, paths, opts.taskList()...)
+	case len(binary) == len(inputs):
+		result, statusCode, err = hdler.PerformWithBinary(opts.secretID, binary, opts.taskList()...)
+	default:
+		return usagef("inputs of a request must be all urls, all paths or stdin")
+	}
+	return printResult(opts, result, statusCode, err, parseRaw)
+}
+
+func runSpeechAsync(args []string) error {
+	var (
+		opts     = new(options)
+		fs       = newFlagSet("speech async", "speech async [flags] <url>", opts)
+		callback = fs.String("callback", "", "callback url of the result")
+		rule     = fs.String("callback-rule", "", "callback rule, \"all\" to callback all results")
+		roomID   = fs.String("room-id", "", "room id of the speech")
+		userID   = fs.String("user-id", "", "user id of the speech")
+		forumID  = fs.String("forum-id", "", "forum id of the speech")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || !isURL(fs.Arg(0)) {
+		return usagef("speech async needs one speech url")
+	}
+
+	hdler, err := speechasync.NewSpeechHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	asyncOpts := []speechasync.SPAsyncOptFunc{
+		speechasync.WithCallbackURL(*callback),
+		speechasync.WithCallbackRule(*rule),
+		speechasync.WithRoomID(*roomID),
+		speechasync.WithUserId(*userID),
+		speechasync.WithFormID(*forumID),
+	}
+	result, statusCode, err := hdler.Perform(opts.secretID, fs.Arg(0), asyncOpts...)
+	return printResult(opts, result, statusCode, err, parseRaw)
+}
+
+func runSpeechStream(args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: tupu speech stream <start|close|status> [flags] ...")
+	}
+
+	var (
+		action   = args[0]
+		opts     = new(options)
+		fs       = newFlagSet("speech stream "+action, "speech stream start [flags] <stream url>\n       tupu speech stream <close|status> [flags] <request id>", opts)
+		callback = fs.String("callback", "", "callback url of the result, required by start")
+		roomID   = fs.String("room-id", "", "room id of the stream")
+		userID   = fs.String("user-id", "", "user id of the stream")
+		forumID  = fs.String("forum-id", "", "forum id of the stream")
+	)
+	if err := parseFlags(fs, opts, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("speech stream %s needs one argument", action)
+	}
+
+	hdler, err := speechstream.NewSpeechStreamHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	var (
+		result     string
+		statusCode int
+		parse      = func(s string) (interface{}, error) {
+			return speechstream.ParseStreamResult(s)
+		}
+	)
+	switch action {
+	case "start":
+		if *callback == "" {
+			return usagef("-callback is required by speech stream start")
+		}
+		streamOpts := []speechstream.StreamOptFunc{
+			speechstream.WithRoomID(*roomID),
+			speechstream.WithUserId(*userID),
+			speechstream.WithFormID(*forumID),
+		}
+		if tasks := opts.taskList(); len(tasks) > 0 {
+			streamOpts = append(streamOpts, speechstream.WithTask(tasks...))
+		}
+		result, statusCode, err = hdler.StartStreamRecognition(opts.secretID, fs.Arg(0), *callback, streamOpts...)
+	case "close":
+		result, statusCode, err = hdler.CloseRecognitionTask(opts.secretID, fs.Arg(0))
+	case "status":
+		result, statusCode, err = hdler.QueryStatus(opts.secretID, fs.Arg(0))
+	default:
+		return usagef("unknown speech stream command %q, want start, close or status", action)
+	}
+	return printResult(opts, result, statusCode, err, parse)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textasync"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textsync"
+)
+
+func runText(args []string) error {
+	var (
+		opts      = new(options)
+		fs        = newFlagSet("text", "text [flags] [text...|-]\n\nThe non-empty lines of stdin are the texts if no text is given.", opts)
+		async     = fs.Bool("async", false, "use text async recognition, -callback is required")
+		callback  = fs.String("callback", "", "callback url of text async recognition")
+		query     = fs.String("query", "", "query the text async result of the request id instead")
+		userID    = fs.String("user-id", "", "user id of the texts")
+		forumID   = fs.String("forum-id", "", "forum id of the texts")
+		moderated = fs.Bool("verdict", false, "print the merged verdict of each text, long texts are chunked")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	if *async || *query != "" {
+		return runTextAsync(opts, fs.Args(), *callback, *query, *userID, *forumID)
+	}
+
+	contents, err := readLines(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return usagef("no text")
+	}
+	texts := make([]textsync.TextAsyncItem, len(contents))
+	for i, content := range contents {
+		texts[i] = textsync.TextAsyncItem{
+			Content:   content,
+			ContentID: fmt.Sprint(i),
+			UserID:    *userID,
+			ForumID:   *forumID,
+		}
+	}
+
+	hdler, err := textsync.NewTextHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	if *moderated {
+		verdicts, statusCode, err := hdler.Moderate(opts.secretID, texts)
+		if err != nil {
+			return err
+		}
+		return printTyped(opts, verdicts, statusCode)
+	}
+	result, statusCode, err := hdler.Perform(opts.secretID, texts)
+	return printResult(opts, result, statusCode, err, func(s string) (interface{}, error) {
+		r, err := textsync.ParseTextResult(s)
+		if err == nil {
+			r.Raw = ""
+		}
+		return r, err
+	})
+}
+
+func runTextAsync(opts *options, args []string, callback, query, userID, forumID string) error {
+	hdler, err := textasync.NewTextAsyncHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	parse := func(s string) (interface{}, error) {
+		r, err := textasync.ParseAsyncResult(s)
+		if err == nil {
+			r.Raw = ""
+		}
+		return r, err
+	}
+	if query != "" {
+		result, statusCode, err := hdler.QueryResult(opts.secretID, query)
+		return printResult(opts, result, statusCode, err, parse)
+	}
+
+	if callback == "" {
+		return usagef("-callback is required by text async recognition")
+	}
+	contents, err := readLines(args)
+	if err != nil {
+		return err
+	}
+	if len(contents) == 0 {
+		return usagef("no text")
+	}
+	texts := make([]textasync.TextItem, len(contents))
+	for i, content := range contents {
+		texts[i] = textasync.TextItem{
+			Content:   content,
+			ContentID: fmt.Sprint(i),
+			UserID:    userID,
+			ForumID:   forumID,
+		}
+	}
+
+	var asyncOpts []textasync.AsyncOptFunc
+	if tasks := opts.taskList(); len(tasks) > 0 {
+		asyncOpts = append(asyncOpts, textasync.WithTask(tasks...))
+	}
+	result, statusCode, err := hdler.Perform(opts.secretID, callback, texts, asyncOpts...)
+	return printResult(opts, result, statusCode, err, parseRaw)
+}
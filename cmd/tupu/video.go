@@ -0,0 +1,144 @@
+package main
+
+import (
+	"github.com/tuputech/tupu-go-sdk/recognition/video/videoasync"
+	"github.com/tuputech/tupu-go-sdk/recognition/video/videosync"
+)
+
+func runVideo(args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: tupu video <sync|async|result|close|rate> [flags] ...")
+	}
+	switch args[0] {
+	case "sync":
+		return runVideoSync(args[1:])
+	case "async", "result", "close", "rate":
+		return runVideoAsync(args[0], args[1:])
+	default:
+		return usagef("unknown video command %q, want sync, async, result, close or rate", args[0])
+	}
+}
+
+func runVideoSync(args []string) error {
+	var (
+		opts      = new(options)
+		fs        = newFlagSet("video sync", "video sync [flags] <url|path|->...", opts)
+		tag       = fs.String("tag", "", "tag of the videos")
+		interval  = fs.Uint("interval", 0, "seconds between the frames taken, default the api default")
+		maxFrames = fs.Uint("max-frames", 0, "max number of frames taken, default the api default")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	hdler, err := videosync.NewSyncHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	var syncOpts []videosync.SyncOptFunc
+	if *tag != "" {
+		syncOpts = append(syncOpts, videosync.WithTag(*tag))
+	}
+	if *interval > 0 {
+		syncOpts = append(syncOpts, videosync.WithInterval(uint8(*interval)))
+	}
+	if *maxFrames > 0 {
+		syncOpts = append(syncOpts, videosync.WithMaxFrames(uint16(*maxFrames)))
+	}
+	if tasks := opts.taskList(); len(tasks) > 0 {
+		syncOpts = append(syncOpts, videosync.WithTask(tasks...))
+	}
+
+	var (
+		urls, paths, binary = splitInputs(inputs)
+		result              string
+		statusCode          int
+	)
+	switch {
+	case len(urls) == len(inputs):
+		result, statusCode, err = hdler.PerformWithURL(opts.secretID, urls, syncOpts...)
+	case len(paths) == len(inputs):
+		result, statusCode, err = hdler.PerformWithPath(opts.secretID, paths, syncOpts...)
+	case len(binary) == len(inputs):
+		result, statusCode, err = hdler.PerformWithBinary(opts.secretID, binary, syncOpts...)
+	default:
+		return usagef("inputs of a request must be all urls, all paths or stdin")
+	}
+	return printResult(opts, result, statusCode, err, parseRaw)
+}
+
+func runVideoAsync(action string, args []string) error {
+	var (
+		opts     = new(options)
+		fs       = newFlagSet("video "+action, "video async [flags] <url>\n       tupu video <result|close> [flags] <video id>\n       tupu video rate [flags]", opts)
+		callback = fs.String("callback", "", "callback url of the result, required by async")
+		interval = fs.Uint("interval", 0, "seconds between the frames taken, default the api default")
+		audio    = fs.Bool("audio", false, "recognize the audio of the video")
+		realTime = fs.Bool("realtime", false, "callback the results in real time")
+	)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	hdler, err := videoasync.NewVideoAsyncHandler(opts.privateKey)
+	if err != nil {
+		return err
+	}
+	opts.configure(hdler)
+
+	var (
+		result     string
+		statusCode int
+		parse      = func(s string) (interface{}, error) {
+			r, err := videoasync.ParseVideoResult(s)
+			if err == nil {
+				r.Raw = ""
+			}
+			return r, err
+		}
+	)
+	switch action {
+	case "rate":
+		if fs.NArg() != 0 {
+			return usagef("video rate takes no argument")
+		}
+		result, statusCode, err = hdler.QueryRate(opts.secretID)
+		parse = func(s string) (interface{}, error) {
+			return videoasync.ParseRateResult(s)
+		}
+	case "async":
+		if fs.NArg() != 1 || !isURL(fs.Arg(0)) {
+			return usagef("video async needs one video url")
+		}
+		if *callback == "" {
+			return usagef("-callback is required by video async")
+		}
+		asyncOpts := []videoasync.AsyncOptFunc{
+			videoasync.WithAudio(*audio),
+			videoasync.WithRealTimeCallback(*realTime),
+		}
+		if *interval > 0 {
+			asyncOpts = append(asyncOpts, videoasync.WithInterval(uint8(*interval)))
+		}
+		if tasks := opts.taskList(); len(tasks) > 0 {
+			asyncOpts = append(asyncOpts, videoasync.WithTask(tasks...))
+		}
+		result, statusCode, err = hdler.Perform(opts.secretID, fs.Arg(0), *callback, asyncOpts...)
+	default:
+		if fs.NArg() != 1 {
+			return usagef("video %s needs one video id", action)
+		}
+		if action == "close" {
+			result, statusCode, err = hdler.CloseRecognitionTask(opts.secretID, fs.Arg(0))
+		} else {
+			result, statusCode, err = hdler.QueryRecognitionResult(opts.secretID, fs.Arg(0))
+		}
+	}
+	return printResult(opts, result, statusCode, err, parse)
+}
@@ -7,6 +7,9 @@ import (
 	//rcn "recognition"
 	"time"
 
+	"github.com/tuputech/tupu-go-sdk/lib/cache"
+	"github.com/tuputech/tupu-go-sdk/lib/imagehash"
+	"github.com/tuputech/tupu-go-sdk/lib/imageproc"
 	rcn "github.com/tuputech/tupu-go-sdk/recognition"
 )
 
@@ -26,6 +29,18 @@ func main() {
 	// }
 	// handler.Client = &http.Client{Transport: tr}
 
+	//Optional Step: cache the results, the images submitted again are not recognized twice
+	handler.SetCache(cache.NewLRUCache(cache.DefaultCapacity), time.Hour)
+
+	//Optional Step: the results of near-duplicate local or binary images are inferred instead of recognized
+	handler.SetDedup(imagehash.NewIndex(imagehash.DHash, imagehash.DefaultIndexCapacity), 4)
+
+	//Optional Step: downscale and re-encode local or binary images before uploading
+	handler.SetPreprocessor(imageproc.NewProcessor(imageproc.WithMaxDimension(1024)))
+
+	//Optional Step: recognize the sampled frames of animated GIFs, one result is aggregated per GIF
+	handler.SetFrameSampler(imageproc.NewFrameSampler(imageproc.WithSceneChange(10)), nil)
+
 	images1 := []string{"your image url"}
 
 	// just for images
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	rcn "github.com/tuputech/tupu-go-sdk/recognition"
+	"github.com/tuputech/tupu-go-sdk/recognition/policy"
+)
+
+// rules is usually kept in a file and loaded by policy.LoadPolicy
+const rules = `{
+	"name": "community",
+	"defaultAction": "pass",
+	"rules": [
+		{"name": "porn-block", "priority": 10, "task": "54bcfc6c329af61034f7c2fc", "labels": [0], "minRate": 0.8, "action": "block"},
+		{"name": "porn-review", "priority": 5, "task": "54bcfc6c329af61034f7c2fc", "review": true, "action": "review"},
+		{"name": "contact-ocr", "priority": 7, "keywords": ["wechat", "qq"], "action": "review"}
+	]
+}`
+
+func main() {
+	// step1. create the policy
+	p, err := policy.ParsePolicy([]byte(rules))
+	if err != nil {
+		fmt.Printf("Failed: %v\n", err)
+		return
+	}
+
+	// step2. recognize the images
+	secretID := "Your SecretID"
+	handler, err := rcn.NewHandler("rsa_private_key.pem")
+	if err != nil {
+		fmt.Printf("Failed: %v\n", err)
+		return
+	}
+	result, statusCode, err := handler.PerformWithURL(secretID, []string{"your image url"}, nil, nil)
+	if err != nil || statusCode > 299 {
+		fmt.Printf("Failed: %v, status code: %v\n", err, statusCode)
+		return
+	}
+
+	// step3. decide the action of every image
+	decisions, err := p.Evaluate(result)
+	if err != nil {
+		fmt.Printf("Failed: %v\n", err)
+		return
+	}
+	for _, d := range decisions {
+		fmt.Println(d.Explain())
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	tupuaudio "github.com/tuputech/tupu-go-sdk/lib/audio"
+	SPCHAS "github.com/tuputech/tupu-go-sdk/recognition/speech/speechasync"
+	SPCHRT "github.com/tuputech/tupu-go-sdk/recognition/speech/speechroute"
+	SPCHS "github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
+)
+
+func main() {
+
+	var (
+		// step1. get your secretID
+		secretID string = "your secretID"
+		// your rsa_private_key local path
+		privateKeyPath string = "rsa_private_key.pem"
+		// your receive recognition result server url
+		callbackUrl string = "your server url"
+		// your need to recogniton speech file
+		filePath string = "your speech filePath"
+		// the url of the same speech, it's used when the speech is routed to async recognition
+		speechUrl string = "your speech url"
+	)
+
+	fileBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("Could not load voice: %v", err)
+		return
+	}
+
+	// step2. probe the speech (optional)
+	if info, err := tupuaudio.Probe(fileBytes); err == nil {
+		fmt.Printf("- Format: %v Duration: %v SampleRate: %v Channels: %v Bitrate: %v\n", info.Format, info.Duration, info.SampleRate, info.Channels, info.Bitrate)
+	}
+
+	// step3. create speech handlers and router
+	syncHandler, err := SPCHS.NewSyncHandler(privateKeyPath)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+	asyncHandler, err := SPCHAS.NewSpeechHandler(privateKeyPath)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+	router, err := SPCHRT.NewRouter(syncHandler, asyncHandler, SPCHRT.WithMaxSyncDuration(30*time.Second))
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+
+	// step4. recognition, the speech longer than 30s is sent to async recognition
+	decision, result, statusCode, err := router.Perform(secretID, filepath.Base(filePath), fileBytes, speechUrl, nil, SPCHAS.WithCallbackURL(callbackUrl))
+	if err != nil {
+		fmt.Printf("Failed: %v\n", err)
+		return
+	}
+	fmt.Printf("- Route: %v (%v)\n- Status-Code: %v\n- Result: %v\n", decision.Route, decision.Reason, statusCode, result)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	SPSTRM "github.com/tuputech/tupu-go-sdk/recognition/speech/speechstream"
+)
+
+func main() {
+
+	var (
+		// step1. get your secretID
+		secretID string = "your secretID"
+		// your rsa_private_key local path
+		privateKeyPath string = "rsa_private_key.pem"
+		// your receive recognition result server url
+		callbackUrl string = "your server url"
+		// the speech streams of your live rooms
+		roomStreams = map[string]string{
+			"room1": "your speech url",
+			"room2": "your speech url",
+		}
+		spstrmHandler *SPSTRM.SpeechStreamHandler
+		manager       *SPSTRM.SessionManager
+		err           error
+	)
+
+	// step2. create speech handler and session manager
+	if spstrmHandler, err = SPSTRM.NewSpeechStreamHandler(privateKeyPath); err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	// every session queries its status every 30s, reconnects at most 3 times
+	// and is closed automatically after 2 hours
+	manager, err = SPSTRM.NewSessionManager(spstrmHandler, secretID,
+		SPSTRM.WithCheckInterval(30*time.Second),
+		SPSTRM.WithMaxReconnects(3),
+		SPSTRM.WithMaxDuration(2*time.Hour),
+	)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	defer manager.CloseAll()
+
+	// step3. start a session for every room, the session is closed when ctx is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for roomID, streamUrl := range roomStreams {
+		session, err := manager.Start(ctx, streamUrl, callbackUrl,
+			SPSTRM.WithRoomID(roomID),
+			SPSTRM.WithCallbackRules(SPSTRM.CallbackAllRecognition),
+		)
+		if err != nil {
+			fmt.Println("start session failed:", roomID, err)
+			continue
+		}
+		fmt.Println("session started:", roomID, session.RequestID())
+	}
+
+	// step4. list the active sessions
+	time.Sleep(time.Minute)
+	for _, session := range manager.Sessions() {
+		fmt.Println("- requestId:", session.RequestID(), "reconnects:", session.Reconnects(), "status:", session.Status())
+	}
+}
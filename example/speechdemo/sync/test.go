@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"time"
 
 	"github.com/bitly/go-simplejson"
+	"github.com/tuputech/tupu-go-sdk/lib/audio"
 	spch "github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
 )
 
@@ -31,6 +33,31 @@ func main() {
 
 	// test demo3
 	testSpeechAPIWithBinary(secretID, speechHandler)
+
+	// test demo4
+	testLongAudio(secretID, speechHandler)
+}
+
+func testLongAudio(secretID string, speechHandler *spch.SyncHandler) {
+	// a long wav file is split at silence, and the segments are recognized concurrently
+	filePath := "your long wav filePath"
+	fileBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("Could not load voice: %v", err)
+		return
+	}
+	splitter := audio.NewSplitter(audio.WithSilenceSplit(audio.DefaultSilenceThreshold, audio.DefaultMinSilence))
+	timeline, err := speechHandler.PerformLongAudio(context.Background(), secretID, filepath.Base(filePath), fileBytes, nil, spch.WithSplitter(splitter))
+	if timeline == nil {
+		fmt.Printf("Failed: %v\n", err)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed: %v, failed segments: %v\n", err, len(timeline.Failed()))
+	}
+	for _, entry := range timeline.Entries {
+		fmt.Printf("- Task: [%v] %v - %v label: %v review: %v\n", entry.TaskID, entry.Start, entry.End, entry.Label, entry.Review)
+	}
 }
 
 func testSpeechAPIWithBinary(secretID string, speechHandler *spch.SyncHandler) {
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	textAsync "github.com/tuputech/tupu-go-sdk/recognition/text/textasync"
+)
+
+func main() {
+
+	var (
+		// step1. get your secretID
+		secretID string = "your secretID"
+		// your rsa_private_key local path
+		privateKeyPath string = "rsa_private_key.pem"
+		// your receive recognition result server url
+		callbackUrl string = "your server url"
+		// the address of callback server listening
+		callbackAddr string = ":8080"
+	)
+
+	// step2. create text handler
+	textHandler, err := textAsync.NewTextAsyncHandler(privateKeyPath)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+
+	// step3. (optional) receive the recognition result
+	receiver, err := textAsync.NewCallbackReceiver(func(r *textAsync.AsyncResult) error {
+		fmt.Printf("- requestId: %v\n- Code: %v %v\n", r.RequestID, r.Code, r.Message)
+		for k, v := range r.Tasks {
+			fmt.Printf("- Task: [%v]\n%v\n", k, v)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+	go http.ListenAndServe(callbackAddr, receiver)
+
+	// step4. submit texts, every request carries at most 100 texts
+	texts := []textAsync.TextItem{
+		{Content: "your long text", ContentID: "article-1"},
+		{Content: "your long text", ContentID: "article-2"},
+	}
+	requestIDs, err := textHandler.PerformInBatches(secretID, callbackUrl, texts, textAsync.DefaultBatchSize,
+		textAsync.WithCallbackRule(textAsync.CallbackRuleALL),
+	)
+	if err != nil {
+		fmt.Println("submit texts failed:", err)
+	}
+
+	// step5. (optional) query the result by requestId
+	time.Sleep(time.Minute)
+	for _, requestID := range requestIDs {
+		result, statusCode, err := textHandler.QueryResult(secretID, requestID)
+		fmt.Println("query result: ", result, "\nstatus code: ", statusCode, "\nerror: ", err)
+	}
+}
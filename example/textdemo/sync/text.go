@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/bitly/go-simplejson"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textfilter"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textnorm"
 	textSync "github.com/tuputech/tupu-go-sdk/recognition/text/textsync"
 )
 
@@ -31,6 +33,48 @@ func main() {
 	// start recognition and get result
 	result, statusCode, err := textHandler.Perform(secretID, texts)
 	printResult(result, statusCode, err)
+
+	// step4. (optional) long text is split into chunks, and the results of chunks are merged
+	textHandler.SetChunkOptions(textSync.DefaultMaxChunkRunes, textSync.DefaultChunkOverlap)
+	// (optional) the texts and chunks are sent in batches of at most 100 texts
+	textHandler.SetBatchOptions(textSync.DefaultMaxBatchItems, textSync.DefaultMaxBatchRunes)
+	// (optional) zero-width characters, homoglyphs and spaced-out letters are folded before recognition
+	textHandler.SetNormalizer(textnorm.NewNormalizer())
+	// (optional) texts hitting the block list are judged locally without calling TUPU,
+	// and the hits of the annotate list are sent with the texts
+	prefilter, err := textfilter.NewPrefilter(textfilter.KeywordList{
+		Name:     "spam",
+		Label:    1,
+		Action:   textfilter.ActionBlock,
+		Keywords: []string{"your keyword"},
+	}, textfilter.KeywordList{
+		Name:     "watch",
+		Label:    2,
+		Action:   textfilter.ActionAnnotate,
+		Keywords: []string{"your other keyword"},
+	})
+	if err == nil {
+		textHandler.SetPrefilter(prefilter)
+	}
+	verdicts, statusCode, err := textHandler.Moderate(secretID, texts)
+	if err != nil {
+		fmt.Printf("Failed: %v, Status-Code: %v\n", err, statusCode)
+		return
+	}
+	for _, verdict := range verdicts {
+		for taskID, r := range verdict.Tasks {
+			fmt.Printf("- Task: [%v] label: %v review: %v chunks: %v local: %v\n", taskID, r.Label, r.Review, verdict.Chunks, verdict.Local)
+			for _, detail := range r.Details {
+				fmt.Printf("\t[%v, %v) %v\n", detail.Start, detail.End, detail.Keyword)
+			}
+		}
+		for _, url := range verdict.URLs {
+			fmt.Printf("- URL: %v\n", url.Value)
+		}
+		for _, phone := range verdict.Phones {
+			fmt.Printf("- Phone: %v\n", phone.Value)
+		}
+	}
 }
 
 func printResult(result string, statusCode int, err error) {
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	tupujobstore "github.com/tuputech/tupu-go-sdk/lib/jobstore"
+	VDASHdler "github.com/tuputech/tupu-go-sdk/recognition/video/videoasync"
+)
+
+func main() {
+
+	// step1. get your secretID
+	var (
+		// your secretId
+		secretID string = "your sid"
+		// your rsa_private_key local path
+		privateKeyPath string = "your rsa private key"
+		// your video url
+		videoUrl string = "your need to recognition video url"
+		// your receive recognition result server url, TUPU will post the result to it
+		callbackUrl string = "your server url"
+		// the address of callback server listening
+		callbackAddr string = ":8080"
+		// the file to save the submitted jobs
+		jobStorePath string = "video_jobs.log"
+		// video handler
+		vdasHdler *VDASHdler.AsyncHandler
+		store     *tupujobstore.FileStore
+		manager   *VDASHdler.JobManager
+		job       *VDASHdler.Job
+		result    *VDASHdler.VideoResult
+		err       error
+	)
+
+	// step2. create video handler and job manager
+	if vdasHdler, err = VDASHdler.NewVideoAsyncHandler(privateKeyPath); err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	if store, err = tupujobstore.OpenFileStore(jobStorePath); err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	defer store.Close()
+	// the result is queried every 5s, 10s, 20s ... at most every 1min if no callback is received
+	manager, err = VDASHdler.NewJobManager(vdasHdler, secretID,
+		VDASHdler.WithPollInterval(5*time.Second),
+		VDASHdler.WithMaxPollInterval(time.Minute),
+		VDASHdler.WithJobStore(store),
+	)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	defer manager.Close()
+
+	// (optional) resume the jobs submitted before restart, jobs older than 1 day are closed
+	if jobs, err := manager.Recover(24 * time.Hour); err == nil {
+		fmt.Println("recovered jobs:", len(jobs))
+	}
+
+	// step3. (optional) receive callback of TUPU
+	receiver, err := manager.NewCallbackReceiver()
+	if err != nil {
+		fmt.Println("-------- ERROR ----------", err)
+		return
+	}
+	go http.ListenAndServe(callbackAddr, receiver)
+
+	// step4. submit video, it blocks while running jobs reach the rate of your secretId
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	if job, err = manager.Submit(ctx, videoUrl, callbackUrl, VDASHdler.WithInterval(5)); err != nil {
+		fmt.Println("submit video failed:", err)
+		return
+	}
+	fmt.Println("videoId:", job.VideoID)
+
+	// step5. wait for the final result, call job.Cancel() to close the task
+	if result, err = job.Wait(ctx); err != nil {
+		fmt.Println("wait video result failed:", err)
+		// close the recognition task on TUPU
+		job.Cancel()
+		return
+	}
+
+	fmt.Printf("- Code: %v %v\n- Status: %v\n", result.Code, result.Message, result.Status)
+	for k, v := range result.Tasks {
+		fmt.Printf("- Task: [%v]\n%v\n", k, v)
+	}
+}
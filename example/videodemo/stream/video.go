@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	VDSTRM "github.com/tuputech/tupu-go-sdk/recognition/video/videostream"
+)
+
+func main() {
+
+	var (
+		// step1. get your secretID
+		secretID string = "your secretID"
+		// your rsa_private_key local path
+		privateKeyPath string = "rsa_private_key.pem"
+		// your receive recognition result server url
+		callbackUrl string = "your server url"
+		// the address of callback server listening
+		callbackAddr string = ":8080"
+		// your need to recogniton video stream url
+		streamUrl  string = "your video stream url"
+		rlt        *VDSTRM.StreamResult
+		requestId  string
+		result     string
+		statusCode int
+		err        error
+	)
+
+	// step2. create video stream handler
+	vdstrmHandler, err := VDSTRM.NewVideoStreamHandler(privateKeyPath)
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+
+	// step3. (optional) receive the recognition result
+	receiver, err := VDSTRM.NewCallbackReceiver(func(r *VDSTRM.CallbackResult) error {
+		fmt.Printf("- requestId: %v roomId: %v\n", r.RequestID, r.RoomID)
+		for k, v := range r.Tasks {
+			fmt.Printf("- Task: [%v]\n%v\n", k, v)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("-------- ERROR ----------")
+		return
+	}
+	go http.ListenAndServe(callbackAddr, receiver)
+
+	// step4. start recognition
+	// WithXXXX function is optional for api request params
+	callbackRules := map[string][]VDSTRM.TaskCallbackRule{
+		"54bcfc6c329af61034f7c2fc": {{Label: 1, Review: true}},
+	}
+	result, statusCode, err = vdstrmHandler.StartStreamRecognition(secretID, streamUrl, callbackUrl,
+		VDSTRM.WithRoomID("room1"),
+		VDSTRM.WithInterval(5),
+		VDSTRM.WithCallbackRules(callbackRules),
+		VDSTRM.WithTask("54bcfc6c329af61034f7c2fc"),
+	)
+	if err != nil {
+		fmt.Println("start recognition failed:", err)
+		return
+	}
+
+	// step5. parse response body to get requestId
+	if rlt, err = VDSTRM.ParseStreamResult(result); err != nil || len(rlt.RequestID()) == 0 {
+		fmt.Println("start recognition failed, status code:", statusCode, "result:", result)
+		return
+	}
+	requestId = rlt.RequestID()
+
+	// step6. (optional) query recognition status
+	time.Sleep(time.Minute)
+	result, statusCode, err = vdstrmHandler.QueryStatus(secretID, requestId)
+	fmt.Println("query recognition status result: ", result, "\nstatus code: ", statusCode, "\nerror: ", err)
+
+	// step7. close recognition task
+	result, statusCode, err = vdstrmHandler.CloseRecognitionTask(secretID, requestId)
+	fmt.Println("close recognition task result: ", result, "\nstatus code: ", statusCode, "\nerror: ", err)
+}
@@ -2,4 +2,4 @@ module github.com/tuputech/tupu-go-sdk
 
 go 1.16
 
-require github.com/bitly/go-simplejson v0.5.0 // indirect
+require github.com/bitly/go-simplejson v0.5.0
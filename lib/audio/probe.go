@@ -0,0 +1,216 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"time"
+)
+
+// ErrUnknownAudio is returned when the format can't be probed
+var ErrUnknownAudio = errors.New("unknown audio format")
+
+// Info is the metadata of an audio file
+type Info struct {
+	// Format is "wav", "mp3", "amr" or "amr-wb"
+	Format     string
+	Duration   time.Duration
+	SampleRate int
+	Channels   int
+	// Bitrate is the average bits per second
+	Bitrate int
+	// Frames is the number of MP3 or AMR frames, 0 for WAV
+	Frames int
+}
+
+var (
+	// mp3Bitrates is the bitrate in kbps by [version is MPEG1][layer-1][index]
+	mp3Bitrates = [2][3][16]int{
+		{ // MPEG2 and MPEG2.5
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		},
+		{ // MPEG1
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		},
+	}
+	// mp3SampleRates is the sample rate of MPEG1, halved for MPEG2 and quartered for MPEG2.5
+	mp3SampleRates = [3]int{44100, 48000, 32000}
+
+	// amrFrameSizes is the size of the speech bits by frame type, the header byte excluded
+	amrFrameSizes   = [16]int{12, 13, 15, 17, 19, 20, 26, 31, 5, 0, 0, 0, 0, 0, 0, 0}
+	amrWBFrameSizes = [16]int{17, 23, 32, 36, 40, 46, 50, 58, 60, 5, 0, 0, 0, 0, 0, 0}
+)
+
+// amrFrameDuration is the play time of an AMR frame
+const amrFrameDuration = 20 * time.Millisecond
+
+// ProbeFile reads the file and probes it
+func ProbeFile(path string) (*Info, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Probe(buf)
+}
+
+// Probe returns the metadata of a WAV, MP3 or AMR file
+func Probe(buf []byte) (*Info, error) {
+	switch {
+	case len(buf) >= 12 && string(buf[:4]) == "RIFF" && string(buf[8:12]) == "WAVE":
+		return probeWAV(buf)
+	case bytes.HasPrefix(buf, []byte("#!AMR-WB\n")):
+		return probeAMR(buf[9:], "amr-wb", 16000, amrWBFrameSizes)
+	case bytes.HasPrefix(buf, []byte("#!AMR\n")):
+		return probeAMR(buf[6:], "amr", 8000, amrFrameSizes)
+	case bytes.HasPrefix(buf, []byte("ID3")) || (len(buf) >= 2 && buf[0] == 0xFF && buf[1]&0xE0 == 0xE0):
+		return probeMP3(buf)
+	default:
+		return nil, ErrUnknownAudio
+	}
+}
+
+func probeWAV(buf []byte) (*Info, error) {
+	w, err := ParseWAV(buf)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{
+		Format:     "wav",
+		Duration:   w.Duration(),
+		SampleRate: w.Format.SampleRate,
+		Channels:   w.Format.Channels,
+		Bitrate:    w.Format.ByteRate() * 8,
+	}, nil
+}
+
+func probeAMR(frames []byte, format string, sampleRate int, sizes [16]int) (*Info, error) {
+	info := &Info{Format: format, SampleRate: sampleRate, Channels: 1}
+	for i := 0; i < len(frames); {
+		frameType := (frames[i] >> 3) & 0x0F
+		i += 1 + sizes[frameType]
+		info.Frames++
+	}
+	info.Duration = time.Duration(info.Frames) * amrFrameDuration
+	if info.Duration > 0 {
+		info.Bitrate = int(int64(len(frames)) * 8 * int64(time.Second) / int64(info.Duration))
+	}
+	return info, nil
+}
+
+// probeMP3 scans every frame header, so the duration of VBR files is exact
+func probeMP3(buf []byte) (*Info, error) {
+	var (
+		info    = &Info{Format: "mp3"}
+		i       = 0
+		samples int64
+		bytesIn int64
+	)
+	// skip the ID3v2 tag, its size is a syncsafe integer
+	if len(buf) >= 10 && string(buf[:3]) == "ID3" {
+		size := int(buf[6]&0x7F)<<21 | int(buf[7]&0x7F)<<14 | int(buf[8]&0x7F)<<7 | int(buf[9]&0x7F)
+		i = 10 + size
+		if buf[5]&0x10 != 0 {
+			// footer present
+			i += 10
+		}
+	}
+
+	for i+4 <= len(buf) {
+		if string(buf[i:i+3]) == "TAG" {
+			// ID3v1 tag at the end
+			break
+		}
+		frameLen, frameSamples, sampleRate, channels := parseMP3Header(buf[i:])
+		if frameLen > 0 && i+frameLen > len(buf) {
+			// the last frame is truncated
+			break
+		}
+		if frameLen == 0 || !mp3FrameFollows(buf[i+frameLen:]) {
+			// resync at the next byte
+			i++
+			continue
+		}
+		if info.Frames == 0 {
+			info.SampleRate, info.Channels = sampleRate, channels
+		}
+		info.Frames++
+		samples += int64(frameSamples)
+		bytesIn += int64(frameLen)
+		i += frameLen
+	}
+
+	if info.Frames == 0 || info.SampleRate == 0 {
+		return nil, ErrUnknownAudio
+	}
+	info.Duration = time.Duration(samples * int64(time.Second) / int64(info.SampleRate))
+	if info.Duration > 0 {
+		info.Bitrate = int(bytesIn * 8 * int64(time.Second) / int64(info.Duration))
+	}
+	return info, nil
+}
+
+// mp3FrameFollows reports whether rest starts with a frame sync or an ID3v1 tag, or is too short
+// to tell, so a false sync in the audio data is not taken as a frame
+func mp3FrameFollows(rest []byte) bool {
+	if len(rest) < 3 {
+		return true
+	}
+	return (rest[0] == 0xFF && rest[1]&0xE0 == 0xE0) || string(rest[:3]) == "TAG"
+}
+
+// parseMP3Header returns the frame length, samples per frame, sample rate and channels, 0 length if invalid
+func parseMP3Header(h []byte) (frameLen, samples, sampleRate, channels int) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return
+	}
+	var (
+		version     = (h[1] >> 3) & 0x03 // 0: MPEG2.5, 2: MPEG2, 3: MPEG1
+		layer       = (h[1] >> 1) & 0x03 // 1: III, 2: II, 3: I
+		bitrateIdx  = h[2] >> 4
+		rateIdx     = (h[2] >> 2) & 0x03
+		padding     = int(h[2]>>1) & 0x01
+		channelMode = h[3] >> 6
+	)
+	if version == 1 || layer == 0 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+		return
+	}
+
+	mpeg1 := 0
+	if version == 3 {
+		mpeg1 = 1
+	}
+	bitrate := mp3Bitrates[mpeg1][3-layer][bitrateIdx] * 1000
+	sampleRate = mp3SampleRates[rateIdx]
+	switch version {
+	case 2:
+		sampleRate /= 2
+	case 0:
+		sampleRate /= 4
+	}
+
+	switch layer {
+	case 3: // layer I
+		samples = 384
+		frameLen = (12*bitrate/sampleRate + padding) * 4
+	case 2: // layer II
+		samples = 1152
+		frameLen = 144*bitrate/sampleRate + padding
+	default: // layer III
+		samples = 1152
+		frameLen = 144*bitrate/sampleRate + padding
+		if mpeg1 == 0 {
+			samples = 576
+			frameLen = 72*bitrate/sampleRate + padding
+		}
+	}
+
+	channels = 2
+	if channelMode == 3 {
+		channels = 1
+	}
+	return
+}
@@ -0,0 +1,192 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultSegmentDuration is the default max play time of a segment
+	DefaultSegmentDuration = 10 * time.Second
+	// DefaultSilenceThreshold is the default RMS level in dBFS below which a window is silent
+	DefaultSilenceThreshold = -40.0
+	// DefaultMinSilence is the default min length of the silence a segment is cut at
+	DefaultMinSilence = 300 * time.Millisecond
+
+	// analysisWindow is the length of the window the RMS level is computed on
+	analysisWindow = 20 * time.Millisecond
+)
+
+type (
+	// Segment is a part of the audio encoded as WAV, Offset is its start in the audio
+	Segment struct {
+		Index    int
+		Offset   time.Duration
+		Duration time.Duration
+		Data     []byte
+	}
+
+	// Splitter splits PCM audio into fixed-length or silence-delimited segments
+	Splitter struct {
+		maxDuration      time.Duration
+		minDuration      time.Duration
+		silenceSplit     bool
+		silenceThreshold float64
+		minSilence       time.Duration
+	}
+
+	// SplitOptFunc is a function to set the option of Splitter
+	SplitOptFunc func(*Splitter)
+)
+
+// WithSegmentDuration sets the max play time of a segment
+func WithSegmentDuration(d time.Duration) SplitOptFunc {
+	return func(s *Splitter) {
+		if d > 0 {
+			s.maxDuration = d
+		}
+	}
+}
+
+// WithSilenceSplit cuts a segment at the last silence of at least minSilence within the max
+// duration, the silence is the windows of RMS level below threshold dBFS, such as -40
+func WithSilenceSplit(threshold float64, minSilence time.Duration) SplitOptFunc {
+	return func(s *Splitter) {
+		s.silenceSplit = true
+		s.silenceThreshold = threshold
+		s.minSilence = minSilence
+	}
+}
+
+// WithMinSegmentDuration sets the min play time of a segment cut at silence, default half of the max duration
+func WithMinSegmentDuration(d time.Duration) SplitOptFunc {
+	return func(s *Splitter) {
+		s.minDuration = d
+	}
+}
+
+// NewSplitter is an initializer for a Splitter, the segments are fixed-length without WithSilenceSplit
+func NewSplitter(opts ...SplitOptFunc) *Splitter {
+	s := &Splitter{
+		maxDuration:      DefaultSegmentDuration,
+		silenceThreshold: DefaultSilenceThreshold,
+		minSilence:       DefaultMinSilence,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.minDuration <= 0 || s.minDuration > s.maxDuration {
+		s.minDuration = s.maxDuration / 2
+	}
+	return s
+}
+
+// SplitWAV splits a WAV file into segments
+func (s *Splitter) SplitWAV(buf []byte) ([]Segment, error) {
+	w, err := ParseWAV(buf)
+	if err != nil {
+		return nil, err
+	}
+	return s.SplitPCM(w.Format, w.Data)
+}
+
+// SplitPCM splits the PCM samples into segments
+func (s *Splitter) SplitPCM(format PCMFormat, pcm []byte) ([]Segment, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		align    = format.BlockAlign()
+		maxBytes = bytesOf(format, s.maxDuration)
+		minBytes = bytesOf(format, s.minDuration)
+		segments []Segment
+		start    = 0
+	)
+	pcm = pcm[:len(pcm)-len(pcm)%align]
+	if maxBytes < align {
+		maxBytes = align
+	}
+
+	for start < len(pcm) {
+		end := start + maxBytes
+		if end >= len(pcm) {
+			end = len(pcm)
+		} else if s.silenceSplit {
+			if cut := s.silenceCut(format, pcm, start+minBytes, end); cut > start {
+				end = cut
+			}
+		}
+		segments = append(segments, Segment{
+			Index:    len(segments),
+			Offset:   format.Duration(start),
+			Duration: format.Duration(end - start),
+			Data:     EncodeWAV(format, pcm[start:end]),
+		})
+		start = end
+	}
+	return segments, nil
+}
+
+// silenceCut returns the middle of the last silence in pcm[from:to], -1 if there is no silence
+func (s *Splitter) silenceCut(format PCMFormat, pcm []byte, from, to int) int {
+	var (
+		window    = bytesOf(format, analysisWindow)
+		minWins   = int(s.minSilence / analysisWindow)
+		runEnd    = -1
+		runLength = 0
+	)
+	if window < format.BlockAlign() {
+		window = format.BlockAlign()
+	}
+	if minWins < 1 {
+		minWins = 1
+	}
+
+	// scan backward, so the segment is as long as possible
+	for pos := to - window; pos >= from; pos -= window {
+		if rmsDBFS(format, pcm[pos:pos+window]) < s.silenceThreshold {
+			if runLength == 0 {
+				runEnd = pos + window
+			}
+			runLength++
+			continue
+		}
+		if runLength >= minWins {
+			return alignDown(pos+window+(runEnd-pos-window)/2, format.BlockAlign())
+		}
+		runLength = 0
+	}
+	if runLength >= minWins {
+		runStart := runEnd - runLength*window
+		return alignDown(runStart+(runEnd-runStart)/2, format.BlockAlign())
+	}
+	return -1
+}
+
+// rmsDBFS returns the RMS level of the samples in dBFS, -inf for digital silence
+func rmsDBFS(format PCMFormat, pcm []byte) float64 {
+	var (
+		size  = format.BitsPerSample / 8
+		sum   float64
+		count int
+	)
+	for i := 0; i+size <= len(pcm); i += size {
+		v := sampleLevel(pcm[i:], format.BitsPerSample)
+		sum += v * v
+		count++
+	}
+	if count == 0 || sum == 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(math.Sqrt(sum/float64(count)))
+}
+
+func bytesOf(format PCMFormat, d time.Duration) int {
+	n := int(int64(format.ByteRate()) * int64(d) / int64(time.Second))
+	return alignDown(n, format.BlockAlign())
+}
+
+func alignDown(n, align int) int {
+	return n - n%align
+}
@@ -0,0 +1,174 @@
+// Package audio provide parsing, encoding and splitting of WAV and PCM audio, and probing of
+// WAV, MP3 and AMR audio in pure Go
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// wavFormatPCM is the audio format of integer PCM in the fmt chunk
+	wavFormatPCM = 1
+	// wavFormatExtensible is the audio format of WAVE_FORMAT_EXTENSIBLE, the sub format is PCM
+	wavFormatExtensible = 0xFFFE
+)
+
+var (
+	// ErrNotWAV is returned when the data is not a RIFF WAVE file
+	ErrNotWAV = errors.New("not a wav file")
+	// ErrUnsupportedPCM is returned when the samples are not 8, 16, 24 or 32 bits integer PCM
+	ErrUnsupportedPCM = errors.New("unsupported pcm format")
+)
+
+type (
+	// PCMFormat is the format of raw PCM samples, the samples of channels are interleaved
+	PCMFormat struct {
+		SampleRate    int
+		Channels      int
+		BitsPerSample int
+	}
+
+	// WAV is a parsed WAV file, Data refers to the samples in the original buffer
+	WAV struct {
+		Format PCMFormat
+		Data   []byte
+	}
+)
+
+// BlockAlign returns the size of the samples of all channels at a time
+func (f PCMFormat) BlockAlign() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// ByteRate returns the size of one second of samples
+func (f PCMFormat) ByteRate() int {
+	return f.SampleRate * f.BlockAlign()
+}
+
+// Duration returns the play time of size bytes of samples
+func (f PCMFormat) Duration(size int) time.Duration {
+	if f.ByteRate() == 0 {
+		return 0
+	}
+	return time.Duration(int64(size) * int64(time.Second) / int64(f.ByteRate()))
+}
+
+// Validate checks whether the format is supported
+func (f PCMFormat) Validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return fmt.Errorf("%w: %d Hz, %d channels", ErrUnsupportedPCM, f.SampleRate, f.Channels)
+	}
+	switch f.BitsPerSample {
+	case 8, 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("%w: %d bits", ErrUnsupportedPCM, f.BitsPerSample)
+	}
+}
+
+// Duration returns the play time of the WAV
+func (w *WAV) Duration() time.Duration {
+	return w.Format.Duration(len(w.Data))
+}
+
+// ParseWAV parses the fmt and data chunks of buf, the other chunks are skipped
+func ParseWAV(buf []byte) (*WAV, error) {
+	if len(buf) < 12 || string(buf[:4]) != "RIFF" || string(buf[8:12]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+
+	var (
+		w      = new(WAV)
+		hasFmt = false
+	)
+	for i := 12; i+8 <= len(buf); {
+		var (
+			id   = string(buf[i : i+4])
+			size = int(binary.LittleEndian.Uint32(buf[i+4:]))
+			body = buf[i+8:]
+		)
+		// the size of data chunk of a stream is 0 or larger than the file
+		if size > len(body) || (id == "data" && size == 0) {
+			size = len(body)
+		}
+		body = body[:size]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("%w: short fmt chunk", ErrNotWAV)
+			}
+			audioFormat := binary.LittleEndian.Uint16(body)
+			if audioFormat == wavFormatExtensible && len(body) >= 26 {
+				audioFormat = binary.LittleEndian.Uint16(body[24:])
+			}
+			if audioFormat != wavFormatPCM {
+				return nil, fmt.Errorf("%w: audio format %d", ErrUnsupportedPCM, audioFormat)
+			}
+			w.Format = PCMFormat{
+				Channels:      int(binary.LittleEndian.Uint16(body[2:])),
+				SampleRate:    int(binary.LittleEndian.Uint32(body[4:])),
+				BitsPerSample: int(binary.LittleEndian.Uint16(body[14:])),
+			}
+			if err := w.Format.Validate(); err != nil {
+				return nil, err
+			}
+			hasFmt = true
+		case "data":
+			if !hasFmt {
+				return nil, fmt.Errorf("%w: data chunk before fmt chunk", ErrNotWAV)
+			}
+			// drop the partial sample at the end
+			w.Data = body[:len(body)-len(body)%w.Format.BlockAlign()]
+			return w, nil
+		}
+		// chunks are padded to even size
+		i += 8 + size + size%2
+	}
+	return nil, fmt.Errorf("%w: no data chunk", ErrNotWAV)
+}
+
+// EncodeWAV returns a WAV file of the PCM samples
+func EncodeWAV(format PCMFormat, pcm []byte) []byte {
+	buf := make([]byte, 44+len(pcm))
+	copy(buf, "RIFF")
+	binary.LittleEndian.PutUint32(buf[4:], uint32(36+len(pcm)))
+	copy(buf[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(buf[16:], 16)
+	binary.LittleEndian.PutUint16(buf[20:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(buf[22:], uint16(format.Channels))
+	binary.LittleEndian.PutUint32(buf[24:], uint32(format.SampleRate))
+	binary.LittleEndian.PutUint32(buf[28:], uint32(format.ByteRate()))
+	binary.LittleEndian.PutUint16(buf[32:], uint16(format.BlockAlign()))
+	binary.LittleEndian.PutUint16(buf[34:], uint16(format.BitsPerSample))
+	copy(buf[36:], "data")
+	binary.LittleEndian.PutUint32(buf[40:], uint32(len(pcm)))
+	copy(buf[44:], pcm)
+	return buf
+}
+
+// sampleLevel returns the absolute level of the sample at pcm[0:], from 0 to 1
+func sampleLevel(pcm []byte, bits int) float64 {
+	switch bits {
+	case 8:
+		// 8 bits samples are unsigned
+		return abs(float64(int(pcm[0])-128) / 128)
+	case 16:
+		return abs(float64(int16(binary.LittleEndian.Uint16(pcm))) / 32768)
+	case 24:
+		v := int32(uint32(pcm[0])<<8|uint32(pcm[1])<<16|uint32(pcm[2])<<24) >> 8
+		return abs(float64(v) / 8388608)
+	default:
+		return abs(float64(int32(binary.LittleEndian.Uint32(pcm))) / 2147483648)
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+// Package bulk provide an executor to run many requests with bounded concurrency and rate
+package bulk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is the default number of tasks running at the same time
+const DefaultConcurrency = 4
+
+type (
+	// Executor runs tasks in goroutines, at most concurrency tasks at the same time and at most
+	// rate tasks started per second
+	Executor struct {
+		slots   chan struct{}
+		limiter *Limiter
+		wg      sync.WaitGroup
+	}
+
+	// ExecOptFunc is a function to set the option of Executor
+	ExecOptFunc func(*execConfig)
+
+	execConfig struct {
+		concurrency int
+		rate        float64
+	}
+
+	// Limiter spaces the events evenly at a rate per second, it's safe for concurrent use
+	Limiter struct {
+		mu       sync.Mutex
+		interval time.Duration
+		next     time.Time
+	}
+)
+
+// WithConcurrency sets the max number of tasks running at the same time
+func WithConcurrency(n int) ExecOptFunc {
+	return func(c *execConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithRate sets the max number of tasks started per second, 0 means no limit
+func WithRate(perSecond float64) ExecOptFunc {
+	return func(c *execConfig) {
+		if perSecond >= 0 {
+			c.rate = perSecond
+		}
+	}
+}
+
+// NewExecutor is an initializer for an Executor
+func NewExecutor(opts ...ExecOptFunc) *Executor {
+	conf := &execConfig{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	return &Executor{
+		slots:   make(chan struct{}, conf.concurrency),
+		limiter: NewLimiter(conf.rate),
+	}
+}
+
+// Go waits for a free slot and the rate limit, then runs task in a goroutine. The error of
+// ctx is returned and the task isn't run if ctx is done before that
+func (e *Executor) Go(ctx context.Context, task func(ctx context.Context)) error {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := e.limiter.Wait(ctx); err != nil {
+		<-e.slots
+		return err
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer func() {
+			<-e.slots
+			e.wg.Done()
+		}()
+		task(ctx)
+	}()
+	return nil
+}
+
+// Wait blocks until all started tasks return
+func (e *Executor) Wait() {
+	e.wg.Wait()
+}
+
+// NewLimiter is an initializer for a Limiter, the limiter doesn't block if rate is 0
+func NewLimiter(perSecond float64) *Limiter {
+	l := new(Limiter)
+	if perSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return l
+}
+
+// Wait blocks until the next event is allowed or ctx is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,133 @@
+// Package cache provide storage of TUPU recognition results keyed by content hash,
+// so identical contents submitted again are not recognized twice
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the default max number of entries of LRUCache
+const DefaultCapacity = 10000
+
+type (
+	// Cache is the interface to save recognition results
+	Cache interface {
+		// Get returns the value of key, false if it doesn't exist or is expired
+		Get(key string) (string, bool)
+		// Set saves value of key, it never expires if ttl is not positive
+		Set(key, value string, ttl time.Duration) error
+		// Delete removes the value of key
+		Delete(key string) error
+	}
+
+	// LRUCache is an in-memory Cache evicting the least recently used entries
+	LRUCache struct {
+		mu       sync.Mutex
+		capacity int
+		ll       *list.List
+		entries  map[string]*list.Element
+	}
+
+	lruEntry struct {
+		key      string
+		value    string
+		expireAt time.Time
+	}
+)
+
+// Key returns the hex SHA-256 of parts, parts are separated so ("ab", "c") differs from ("a", "bc")
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewLRUCache is an initializer for a LRUCache, DefaultCapacity is used if capacity is not positive
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get implements Cache
+func (c *LRUCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*lruEntry)
+	if isExpired(entry.expireAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set implements Cache
+func (c *LRUCache) Set(key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expireAt = value, expireTime(ttl)
+		c.ll.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expireAt: expireTime(ttl)})
+	for c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+	return nil
+}
+
+// Delete implements Cache
+func (c *LRUCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	return nil
+}
+
+// Len returns the number of entries, the expired entries not evicted yet are included
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRUCache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
+
+func expireTime(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func isExpired(expireAt time.Time) bool {
+	return !expireAt.IsZero() && time.Now().After(expireAt)
+}
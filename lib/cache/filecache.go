@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type (
+	// FileCache is a Cache saving every entry as a file in a directory, so the
+	// results survive restarts and can be shared by processes on the same host
+	FileCache struct {
+		dir string
+	}
+
+	fileEntry struct {
+		Value    string    `json:"value"`
+		ExpireAt time.Time `json:"expireAt"`
+	}
+)
+
+// NewFileCache is an initializer for a FileCache, dir is created if it doesn't exist
+func NewFileCache(dir string) (*FileCache, error) {
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("[Params ERROR]: cache dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileCache{dir: dir}, nil
+}
+
+// Get implements Cache
+func (c *FileCache) Get(key string) (string, bool) {
+	buf, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return "", false
+	}
+	var entry fileEntry
+	if json.Unmarshal(buf, &entry) != nil {
+		return "", false
+	}
+	if isExpired(entry.ExpireAt) {
+		os.Remove(c.path(key))
+		return "", false
+	}
+	return entry.Value, true
+}
+
+// Set implements Cache, the file is written to a temporary file and renamed, so a reader
+// never sees a partial entry
+func (c *FileCache) Set(key, value string, ttl time.Duration) error {
+	buf, err := json.Marshal(fileEntry{Value: value, ExpireAt: expireTime(ttl)})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(buf); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Delete implements Cache
+func (c *FileCache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Prune removes the expired entries, and returns the number of them
+func (c *FileCache) Prune() (int, error) {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, info := range infos {
+		if info.IsDir() || info.Name()[0] == '.' {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(c.dir, info.Name()))
+		if err != nil {
+			continue
+		}
+		var entry fileEntry
+		if json.Unmarshal(buf, &entry) != nil || isExpired(entry.ExpireAt) {
+			if os.Remove(filepath.Join(c.dir, info.Name())) == nil {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// path returns the file of key, key is hashed so any string can be used as key
+func (c *FileCache) path(key string) string {
+	return filepath.Join(c.dir, Key(key))
+}
@@ -0,0 +1,126 @@
+// Package callback provide a receiver for the callback requests sent by TUPU recognition service
+package callback
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
+)
+
+const (
+	// DefaultMaxBodySize is the default limit of the callback request body
+	DefaultMaxBodySize = 10 << 20
+)
+
+var (
+	// ErrNoResult is returned when the callback body has no result string
+	ErrNoResult = errors.New("no result string")
+	// ErrNoSignature is returned when the callback body has no server signature
+	ErrNoSignature = errors.New("no server signature")
+)
+
+type (
+	// ResultFunc is called with the verified result string of every callback
+	ResultFunc func(result string) error
+
+	// Receiver is a http.Handler to receive and verify the callback of TUPU recognition service
+	Receiver struct {
+		verifier tuputools.Verifier
+		onResult ResultFunc
+		// MaxBodySize limits the size of the callback request body
+		MaxBodySize int64
+		// AllowUnsigned accepts a callback body which is the bare result json without signature
+		AllowUnsigned bool
+	}
+)
+
+// NewReceiver is an initializer for a Receiver, onResult is called with every verified callback result
+func NewReceiver(onResult ResultFunc) (*Receiver, error) {
+	if onResult == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	var (
+		err error
+		rcv = &Receiver{
+			onResult:    onResult,
+			MaxBodySize: DefaultMaxBodySize,
+		}
+	)
+
+	if rcv.verifier, err = tuputools.LoadTupuPublicKey(); err != nil {
+		return nil, err
+	}
+	return rcv, nil
+}
+
+// SetVerifier replaces TUPU's public key to verify the signature of callbacks, such as the key of a private deployment
+func (rcv *Receiver) SetVerifier(verifier tuputools.Verifier) {
+	if verifier != nil {
+		rcv.verifier = verifier
+	}
+}
+
+// Parse verifies the signature of the callback body and returns the result string
+func (rcv *Receiver) Parse(body []byte) (result string, err error) {
+	var (
+		data map[string]interface{}
+		sig  string
+		ok   bool
+	)
+
+	if err = json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("invalid callback body: %v", err)
+	}
+
+	if result, ok = data["json"].(string); !ok {
+		if rcv.AllowUnsigned {
+			return string(bytes.TrimSpace(body)), nil
+		}
+		return "", ErrNoResult
+	}
+	if sig, ok = data["signature"].(string); !ok {
+		return "", ErrNoSignature
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return "", fmt.Errorf("could not decode with Base64: %v", err)
+	}
+	if err = rcv.verifier.Verify([]byte(result), signature); err != nil {
+		return "", fmt.Errorf("could not verify callback: %v", err)
+	}
+	return result, nil
+}
+
+// ServeHTTP implements http.Handler, a callback which fails verification is answered with 400
+func (rcv *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := &bytes.Buffer{}
+	if _, err := body.ReadFrom(io.LimitReader(r.Body, rcv.MaxBodySize)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := rcv.Parse(body.Bytes())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = rcv.onResult(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,110 @@
+// Package cassette provide recording and replaying of the http traffic to TUPU services, so the
+// recognition can be tested without network
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Version is the version of the cassette file format
+const Version = 1
+
+// redacted replaces the value of a secret header
+const redacted = "[REDACTED]"
+
+// secretHeaders are the request headers not stored in cassettes
+var secretHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+type (
+	// Cassette is the recorded interactions in a file
+	Cassette struct {
+		Version      int            `json:"version"`
+		Interactions []*Interaction `json:"interactions"`
+	}
+
+	// Interaction is a request and its response
+	Interaction struct {
+		Request    *Request  `json:"request"`
+		Response   *Response `json:"response"`
+		RecordedAt time.Time `json:"recordedAt"`
+	}
+
+	// Request is a recorded request, the body is stored as its fingerprint only
+	Request struct {
+		Method string `json:"method"`
+		// Endpoint is the url without the secretID at the end
+		Endpoint    string      `json:"endpoint"`
+		SecretID    string      `json:"secretId"`
+		Fingerprint string      `json:"fingerprint"`
+		Header      http.Header `json:"header,omitempty"`
+	}
+
+	// Response is a recorded response, the body keeps the signature of TUPU
+	Response struct {
+		StatusCode int         `json:"statusCode"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body"`
+	}
+)
+
+// Load reads the cassette file at path
+func Load(path string) (*Cassette, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := new(Cassette)
+	if err = json.Unmarshal(buf, c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %v", path, err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("unsupported cassette version %d in %s", c.Version, path)
+	}
+	return c, nil
+}
+
+// Save writes the cassette to path by a temp file and rename, so a cassette is never half written
+func (c *Cassette) Save(path string) error {
+	c.Version = Version
+	buf, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(append(buf, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// redactHeader returns a copy of the header with the secret headers redacted
+func redactHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, key := range secretHeaders {
+		if h.Get(key) != "" {
+			h.Set(key, redacted)
+		}
+	}
+	return h
+}
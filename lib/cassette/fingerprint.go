@@ -0,0 +1,84 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"sort"
+	"strings"
+)
+
+// volatileFields are the fields which differ in every request, they're excluded from fingerprints
+var volatileFields = map[string]bool{
+	"timestamp": true,
+	"nonce":     true,
+	"signature": true,
+}
+
+// Fingerprint returns the hash of the request body without the timestamp, nonce and signature.
+// The fields of a multipart body are sorted, so the boundary and the field order don't matter
+func Fingerprint(contentType string, body []byte) string {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if canonical, err := canonicalMultipart(body, params["boundary"]); err == nil {
+			return hashOf(canonical)
+		}
+	case strings.HasSuffix(mediaType, "json") || json.Valid(body):
+		if canonical, err := canonicalJSON(body); err == nil {
+			return hashOf(canonical)
+		}
+	}
+	return hashOf(body)
+}
+
+// canonicalJSON removes the volatile fields at the top level, the keys are sorted by json.Marshal
+func canonicalJSON(body []byte) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		for key := range volatileFields {
+			delete(m, key)
+		}
+	}
+	return json.Marshal(v)
+}
+
+// canonicalMultipart returns the sorted lines of "name filename hash" of the parts
+func canonicalMultipart(body []byte, boundary string) ([]byte, error) {
+	var (
+		reader = multipart.NewReader(bytes.NewReader(body), boundary)
+		lines  []string
+	)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if volatileFields[part.FormName()] {
+			continue
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, part.FormName()+"\x00"+part.FileName()+"\x00"+hashOf(content))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func hashOf(buf []byte) string {
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
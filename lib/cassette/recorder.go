@@ -0,0 +1,204 @@
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	// ModeRecord sends every request and records it, the existing cassette is replaced
+	ModeRecord Mode = iota
+	// ModeReplay answers every request by the cassette without network
+	ModeReplay
+	// ModeReplayOrRecord answers by the cassette, and sends and records the requests not in it
+	ModeReplayOrRecord
+)
+
+// ErrNoInteraction is returned in ModeReplay when no recorded request matches
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+type (
+	// Mode is the working mode of Recorder
+	Mode int
+
+	// Recorder is a http.RoundTripper recording and replaying the traffic in a cassette file,
+	// a request matches a recorded one by method, endpoint, secretID and body fingerprint
+	Recorder struct {
+		mu        sync.Mutex
+		path      string
+		mode      Mode
+		transport http.RoundTripper
+		cassette  *Cassette
+		// used is the number of times an interaction is replayed, by its index
+		used map[int]int
+	}
+
+	// RecorderOptFunc is a function to set the option of Recorder
+	RecorderOptFunc func(*Recorder)
+)
+
+// WithTransport sets the transport of the requests sent, http.DefaultTransport by default
+func WithTransport(transport http.RoundTripper) RecorderOptFunc {
+	return func(r *Recorder) {
+		if transport != nil {
+			r.transport = transport
+		}
+	}
+}
+
+// NewRecorder is an initializer for a Recorder of the cassette file at path, the cassette
+// must exist in ModeReplay
+func NewRecorder(path string, mode Mode, opts ...RecorderOptFunc) (*Recorder, error) {
+	if tupuerror.StringIsEmpty(path) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		cassette:  &Cassette{Version: Version},
+		used:      make(map[int]int),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode != ModeRecord {
+		c, err := Load(path)
+		switch {
+		case err == nil:
+			r.cassette = c
+		case os.IsNotExist(err) && mode == ModeReplayOrRecord:
+		default:
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Cassette returns the recorded interactions
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// step1. read the body and build the recorded request
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	endpoint, secretID := splitURL(req.URL.Scheme + "://" + req.URL.Host + req.URL.Path)
+	recorded := &Request{
+		Method:      req.Method,
+		Endpoint:    endpoint,
+		SecretID:    secretID,
+		Fingerprint: Fingerprint(req.Header.Get("Content-Type"), body),
+		Header:      redactHeader(req.Header),
+	}
+
+	// step2. replay the matched interaction
+	if r.mode != ModeRecord {
+		if resp := r.replay(recorded, req); resp != nil {
+			return resp, nil
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s%s fingerprint %s", ErrNoInteraction, recorded.Method, endpoint, secretID, recorded.Fingerprint)
+		}
+	}
+
+	// step3. send and record
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request: recorded,
+		Response: &Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(respBody),
+		},
+		RecordedAt: time.Now(),
+	})
+	// the interaction is replayed once already
+	r.used[len(r.cassette.Interactions)-1]++
+	if err = r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay returns the response of the first matched interaction not replayed yet, the
+// last matched one is replayed again when all are used, nil if nothing matches
+func (r *Recorder) replay(recorded *Request, req *http.Request) *http.Response {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := -1
+	for i, it := range r.cassette.Interactions {
+		if !matches(it.Request, recorded) {
+			continue
+		}
+		matched = i
+		if r.used[i] == 0 {
+			break
+		}
+	}
+	if matched < 0 {
+		return nil
+	}
+	r.used[matched]++
+
+	recordedResp := r.cassette.Interactions[matched].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recordedResp.StatusCode, http.StatusText(recordedResp.StatusCode)),
+		StatusCode:    recordedResp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recordedResp.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(recordedResp.Body)),
+		ContentLength: int64(len(recordedResp.Body)),
+		Request:       req,
+	}
+}
+
+func matches(a, b *Request) bool {
+	return a.Method == b.Method && a.Endpoint == b.Endpoint && a.SecretID == b.SecretID && a.Fingerprint == b.Fingerprint
+}
+
+// splitURL splits the url of TUPU apis, which is the api url followed by the secretID
+func splitURL(url string) (endpoint, secretID string) {
+	i := strings.LastIndex(url, "/")
+	if i < 0 {
+		return url, ""
+	}
+	return url[:i+1], url[i+1:]
+}
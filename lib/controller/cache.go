@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+// SetCache provide setting the cache of Recognize, every DataInfo is cached by itself, so the
+// cached files of a batch are not sent again. The task level fields other than fileList of a
+// merged result only refer to the files sent. If the results can't be merged, the response of
+// the files sent is returned as is. nil disables the cache
+func (hdler *Handler) SetCache(cache tupucache.Cache, ttl time.Duration) {
+	hdler.cache = cache
+	hdler.cacheTTL = ttl
+}
+
+// recognizeWithCache sends the DataInfos not in the cache, and merges the cached results into the response
+func (hdler *Handler) recognizeWithCache(secretID string, dataInfoSlice []*tupumodel.DataInfo, tasks []string) (result string, statusCode int, e error) {
+	var (
+		keys     = make([]string, len(dataInfoSlice))
+		items    = make([]tupumodel.ItemResult, len(dataInfoSlice))
+		misses   []*tupumodel.DataInfo
+		missIdxs []int
+		hits     []*tupumodel.DataInfo
+		started  = time.Now()
+	)
+	if hdler.interceptor != nil {
+		defer func() {
+			if len(hits) > 0 && e == nil && statusCode <= 299 {
+				hdler.interceptor(&Exchange{
+					APIURL:     hdler.apiURL,
+					SecretID:   secretID,
+					Tasks:      tasks,
+					Contents:   contentHashes(hits),
+					Started:    started,
+					Duration:   time.Since(started),
+					Cached:     true,
+					StatusCode: statusCode,
+					Result:     result,
+				})
+			}
+		}()
+	}
+
+	// step1. look up every DataInfo in the cache
+	for i, dataInfo := range dataInfoSlice {
+		// a DataInfo failed to hash is sent without caching
+		if keys[i], e = hdler.cacheKey(secretID, dataInfo, tasks); e == nil {
+			if val, ok := hdler.cache.Get(keys[i]); ok && json.Unmarshal([]byte(val), &items[i]) == nil {
+				hits = append(hits, dataInfo)
+				continue
+			}
+		}
+		e = nil
+		items[i] = nil
+		misses = append(misses, dataInfo)
+		missIdxs = append(missIdxs, i)
+	}
+
+	// step2. recognize the missed DataInfos, and save their results
+	if len(misses) > 0 {
+		if result, statusCode, e = hdler.recognize(secretID, misses, tasks); e != nil || statusCode > 299 {
+			return
+		}
+		fresh, err := tupumodel.SplitResult(result, len(misses))
+		if err != nil {
+			// the results can't be cached or merged, return the response as is
+			return
+		}
+		for i, idx := range missIdxs {
+			items[idx] = fresh[i]
+			if len(keys[idx]) == 0 {
+				continue
+			}
+			if val, err := json.Marshal(fresh[i]); err == nil {
+				hdler.cache.Set(keys[idx], string(val), hdler.cacheTTL)
+			}
+		}
+		if len(misses) == len(dataInfoSlice) {
+			return
+		}
+	} else {
+		statusCode = 200
+	}
+
+	// step3. merge the cached results into the response
+	merged, err := tupumodel.MergeResult(result, items)
+	switch {
+	case err == nil:
+		result = merged
+	case len(misses) == 0:
+		e = fmt.Errorf("could not merge cached results: %v", err)
+	}
+	// the response of a successful call is returned as is if it can't be merged
+	return
+}
+
+// cacheKey returns the key of dataInfo, the content is hashed by SHA-256 of the file bytes or the url,
+// and the OtherMsg sent with the file is a part of the key
+func (hdler *Handler) cacheKey(secretID string, dataInfo *tupumodel.DataInfo, tasks []string) (string, error) {
+	content, err := contentHash(dataInfo)
+	if err != nil {
+		return "", err
+	}
+	return tupucache.Key(hdler.apiURL, secretID, strings.Join(tasks, ","), dataInfo.FileType, content, otherMsgKey(dataInfo.OtherMsg)), nil
+}
+
+// otherMsgKey returns msg in json, the keys are sorted by encoding/json
+func otherMsgKey(msg map[string]string) string {
+	if len(msg) == 0 {
+		return ""
+	}
+	val, _ := json.Marshal(msg)
+	return string(val)
+}
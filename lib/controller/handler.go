@@ -18,6 +18,7 @@ import (
 	"strings"
 	"time"
 
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
 	tupuerrorlib "github.com/tuputech/tupu-go-sdk/lib/errorlib"
 	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
 	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
@@ -41,6 +42,7 @@ const (
 
 // Handler is a client-side helper to access TUPU recognition service
 type Handler struct {
+	// Client is the *http.Client object
 	Client   *http.Client
 	apiURL   string
 	signer   tuputools.Signer
@@ -53,7 +55,12 @@ type Handler struct {
 	ContentType string
 	// Timeout is the request Header: Timeout
 	Timeout string
-	// Client is the *http.Client object
+	// cache keeps the results of the same requests, nil disables it
+	cache tupucache.Cache
+	// cacheTTL is how long a cached result is kept
+	cacheTTL time.Duration
+	// interceptor is called after every request, nil disables it
+	interceptor Interceptor
 }
 
 // NewHandlerWithURL is also an initializer for a Handler
@@ -90,6 +97,20 @@ func (hdler *Handler) SetServerURL(url string) {
 	hdler.apiURL = url
 }
 
+// SetTransport sets the transport of the http client, such as a cassette.Recorder
+func (hdler *Handler) SetTransport(transport http.RoundTripper) {
+	if transport != nil {
+		hdler.Client.Transport = transport
+	}
+}
+
+// SetVerifier replaces TUPU's public key to verify the signature of responses, such as the key of a private deployment
+func (hdler *Handler) SetVerifier(verifier tuputools.Verifier) {
+	if verifier != nil {
+		hdler.verifier = verifier
+	}
+}
+
 // SetContentType is the Handler method to setting the UserAgent attribute
 func (hdler *Handler) SetContentType(contentType string) {
 	if tupuerrorlib.StringIsEmpty(contentType) {
@@ -133,9 +154,15 @@ func (hdler *Handler) initHandler() {
 
 // RecognizeWithJSON is one of major method to access recognition api
 func (hdler *Handler) RecognizeWithJSON(jsonStr, secretID string) (result string, statusCode int, err error) {
+	return hdler.RecognizeWithJSONAndURL(hdler.apiURL, jsonStr, secretID)
+}
+
+// RecognizeWithJSONAndURL is same as RecognizeWithJSON, but the request is sent to apiURL
+// instead of the server URL of the Handler, so it is safe to be called concurrently for different apis
+func (hdler *Handler) RecognizeWithJSONAndURL(apiURL, jsonStr, secretID string) (result string, statusCode int, err error) {
 
 	// step1. Invalid parameter check
-	if tupuerrorlib.StringIsEmpty(jsonStr, secretID) {
+	if tupuerrorlib.StringIsEmpty(apiURL, jsonStr, secretID) {
 		result = ""
 		statusCode = 400
 		err = fmt.Errorf("%s, %s", tupuerrorlib.ErrorParamsIsEmpty, tupuerrorlib.GetCallerFuncName())
@@ -145,10 +172,31 @@ func (hdler *Handler) RecognizeWithJSON(jsonStr, secretID string) (result string
 	var (
 		params    map[string]string
 		paramsStr string
-		url       = hdler.apiURL + secretID
+		url       = apiURL + secretID
 		req       *http.Request
 		resp      *http.Response
+		signature string
 	)
+	if hdler.interceptor != nil {
+		var (
+			started = time.Now()
+			body    = jsonStr
+		)
+		defer func() {
+			hdler.interceptor(&Exchange{
+				APIURL:     apiURL,
+				SecretID:   secretID,
+				Tasks:      jsonTasks(body),
+				Contents:   []string{hashContent([]byte(body))},
+				Started:    started,
+				Duration:   time.Since(started),
+				StatusCode: statusCode,
+				Result:     result,
+				Signature:  signature,
+				Err:        err,
+			})
+		}()
+	}
 
 	// step2. get timestamp, nonce, signature
 	if params, err = hdler.GetGeneralParams(secretID); err != nil {
@@ -176,7 +224,7 @@ func (hdler *Handler) RecognizeWithJSON(jsonStr, secretID string) (result string
 		return
 	}
 	// step6. serialize to result string
-	if result, statusCode, err = hdler.processResp(resp); err != nil {
+	if result, signature, statusCode, err = hdler.processResp(resp); err != nil {
 		//log.Fatal(e)
 		return
 	}
@@ -192,12 +240,40 @@ func (hdler *Handler) Recognize(secretID string, dataInfoSlice []*tupumodel.Data
 		e = fmt.Errorf("%s, %s", tupuerrorlib.ErrorParamsIsEmpty, tupuerrorlib.GetCallerFuncName())
 	}
 
+	if hdler.cache != nil {
+		return hdler.recognizeWithCache(secretID, dataInfoSlice, tasks)
+	}
+	return hdler.recognize(secretID, dataInfoSlice, tasks)
+}
+
+func (hdler *Handler) recognize(secretID string, dataInfoSlice []*tupumodel.DataInfo, tasks []string) (result string, statusCode int, e error) {
 	var (
-		url    = hdler.apiURL + secretID
-		req    *http.Request
-		resp   *http.Response
-		params map[string]string
+		url       = hdler.apiURL + secretID
+		req       *http.Request
+		resp      *http.Response
+		params    map[string]string
+		signature string
 	)
+	if hdler.interceptor != nil {
+		var (
+			started  = time.Now()
+			contents = contentHashes(dataInfoSlice)
+		)
+		defer func() {
+			hdler.interceptor(&Exchange{
+				APIURL:     hdler.apiURL,
+				SecretID:   secretID,
+				Tasks:      tasks,
+				Contents:   contents,
+				Started:    started,
+				Duration:   time.Since(started),
+				StatusCode: statusCode,
+				Result:     result,
+				Signature:  signature,
+				Err:        e,
+			})
+		}()
+	}
 
 	if params, e = hdler.GetGeneralParams(secretID); e != nil {
 		statusCode = 400
@@ -214,7 +290,7 @@ func (hdler *Handler) Recognize(secretID string, dataInfoSlice []*tupumodel.Data
 		return
 	}
 
-	if result, statusCode, e = hdler.processResp(resp); e != nil {
+	if result, signature, statusCode, e = hdler.processResp(resp); e != nil {
 		//log.Fatal(e)
 		return
 	}
@@ -356,7 +432,7 @@ func addDataInfoField(writer *multipart.Writer, dataInfo *tupumodel.DataInfo, id
 	return
 }
 
-func (hdler *Handler) processResp(resp *http.Response) (result string, statusCode int, e error) {
+func (hdler *Handler) processResp(resp *http.Response) (result, sig string, statusCode int, e error) {
 	statusCode = resp.StatusCode
 	//if resp.StatusCode > 500 {
 	//	if resp.StatusCode == 502 {
@@ -380,7 +456,6 @@ func (hdler *Handler) processResp(resp *http.Response) (result string, statusCod
 	var (
 		data map[string]string
 		ok   bool
-		sig  string
 	)
 	if err := json.Unmarshal(body.Bytes(), &data); err != nil {
 		if statusCode == 400 || statusCode <= 299 {
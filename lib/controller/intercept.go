@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+type (
+	// Exchange is a request to TUPU and its response, it is passed to the Interceptor after the
+	// request is done
+	Exchange struct {
+		APIURL   string
+		SecretID string
+		// Tasks is the task ids of the request, empty if TUPU decides them by the secretId
+		Tasks []string
+		// Contents is the hash of every content in the request, such as "url:<url>" or
+		// "sha256:<hex>" of a file, or "sha256:<hex>" of the json body
+		Contents []string
+		Started  time.Time
+		Duration time.Duration
+		// Cached means the results of Contents are served from the cache without request
+		Cached     bool
+		StatusCode int
+		Result     string
+		// Signature is the signature of TUPU on Result, empty if Cached
+		Signature string
+		Err       error
+	}
+
+	// Interceptor is called with every request to TUPU and its response
+	Interceptor func(*Exchange)
+)
+
+// SetInterceptor provide setting the function called after every request, such as the audit
+// log. The results served from the cache are passed as one Exchange with Cached. nil disables it
+func (hdler *Handler) SetInterceptor(interceptor Interceptor) {
+	hdler.interceptor = interceptor
+}
+
+// contentHashes returns the hashes of the DataInfos, it is called before the request, because
+// the Buf of DataInfo is consumed by the request
+func contentHashes(dataInfoSlice []*tupumodel.DataInfo) []string {
+	contents := make([]string, len(dataInfoSlice))
+	for i, dataInfo := range dataInfoSlice {
+		content, err := contentHash(dataInfo)
+		if err != nil {
+			content = "error:" + err.Error()
+		}
+		contents[i] = content
+	}
+	return contents
+}
+
+// contentHash returns "url:<url>" of a remote DataInfo, or "sha256:<hex>" of the file bytes
+func contentHash(dataInfo *tupumodel.DataInfo) (string, error) {
+	h := sha256.New()
+	switch {
+	case len(dataInfo.RemoteInfo) > 0:
+		return "url:" + dataInfo.RemoteInfo, nil
+	case len(dataInfo.Path) > 0:
+		file, err := os.Open(dataInfo.Path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	case dataInfo.Buf != nil && dataInfo.Buf.Len() > 0:
+		// Bytes doesn't consume the buffer, it is still sent after hashing
+		h.Write(dataInfo.Buf.Bytes())
+	default:
+		return "", fmt.Errorf("invalid data resource")
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// jsonTasks returns the tasks in the fields of a json body without braces
+func jsonTasks(body string) []string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte("{"+body+"}"), &fields) != nil {
+		return nil
+	}
+	for _, key := range []string{"tasks", "task"} {
+		var tasks []string
+		if raw, ok := fields[key]; ok && json.Unmarshal(raw, &tasks) == nil {
+			return tasks
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package imagehash provide perceptual hashes of images, the hashes of re-encoded or
+// resized copies of an image are within a small Hamming distance
+package imagehash
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	// register decoders of the formats supported by TUPU image recognition
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// AHash is the average hash, fastest but sensitive to gamma and histogram changes
+	AHash Kind = iota + 1
+	// DHash is the difference hash, robust to re-encoding and brightness changes
+	DHash
+	// PHash is the DCT based perception hash, the most robust and slowest one
+	PHash
+
+	// MaxPixels is the max number of pixels of an image decoded by FromBytes, the same as
+	// imageproc.DefaultMaxPixels
+	MaxPixels = 64 << 20
+)
+
+// ErrImageTooLarge is returned by FromBytes for an image of more than MaxPixels pixels
+var ErrImageTooLarge = errors.New("image too large to hash")
+
+type (
+	// Kind is the algorithm of Hash
+	Kind int
+
+	// Hash is a 64 bits perceptual hash
+	Hash uint64
+)
+
+// String returns the name of k
+func (k Kind) String() string {
+	switch k {
+	case AHash:
+		return "ahash"
+	case DHash:
+		return "dhash"
+	case PHash:
+		return "phash"
+	default:
+		return fmt.Sprintf("kind(%d)", int(k))
+	}
+}
+
+// String returns the hex form of h
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// Distance returns the Hamming distance between a and b
+func Distance(a, b Hash) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// Compute returns the hash of img with the algorithm kind
+func Compute(kind Kind, img image.Image) (Hash, error) {
+	switch kind {
+	case AHash:
+		return AverageHash(img), nil
+	case DHash:
+		return DifferenceHash(img), nil
+	case PHash:
+		return PerceptionHash(img), nil
+	default:
+		return 0, fmt.Errorf("unknown hash kind: %v", kind)
+	}
+}
+
+// FromBytes decodes buf as JPEG, PNG or GIF and returns its hash, the size is checked before
+// decoding, so a small file of huge dimensions can't exhaust the memory
+func FromBytes(kind Kind, buf []byte) (Hash, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return 0, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return 0, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return 0, err
+	}
+	return Compute(kind, img)
+}
+
+// AverageHash sets a bit for every pixel of the 8x8 grayscale thumbnail brighter than the mean
+func AverageHash(img image.Image) Hash {
+	var (
+		pixels = grayThumbnail(img, 8, 8)
+		mean   float64
+	)
+	for _, p := range pixels {
+		mean += p
+	}
+	mean /= float64(len(pixels))
+	return bitsAbove(pixels, mean)
+}
+
+// DifferenceHash sets a bit for every pixel of the 9x8 grayscale thumbnail brighter than its right neighbour
+func DifferenceHash(img image.Image) Hash {
+	var (
+		pixels = grayThumbnail(img, 9, 8)
+		h      Hash
+	)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if pixels[y*9+x] > pixels[y*9+x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// PerceptionHash sets a bit for every low frequency DCT coefficient of the 32x32 grayscale
+// thumbnail greater than the median of them
+func PerceptionHash(img image.Image) Hash {
+	const size = 32
+	var (
+		pixels = grayThumbnail(img, size, size)
+		coeffs = dct2D(pixels, size)
+		low    = make([]float64, 0, 64)
+	)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			low = append(low, coeffs[y*size+x])
+		}
+	}
+
+	// the DC coefficient is the mean brightness, it is excluded from the median
+	sorted := append([]float64(nil), low[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	return bitsAbove(low, median)
+}
+
+func bitsAbove(values []float64, threshold float64) Hash {
+	var h Hash
+	for _, v := range values {
+		h <<= 1
+		if v > threshold {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// grayThumbnail scales img to w x h by averaging the pixels of every cell, and returns the luminance
+func grayThumbnail(img image.Image, w, h int) []float64 {
+	var (
+		bounds = img.Bounds()
+		sw, sh = bounds.Dx(), bounds.Dy()
+		sums   = make([]float64, w*h)
+		counts = make([]float64, w*h)
+	)
+	if sw == 0 || sh == 0 {
+		return sums
+	}
+
+	// sample at most 512 pixels per axis, enough for an 8x8 or 32x32 thumbnail
+	stepX, stepY := 1, 1
+	if sw > 512 {
+		stepX = sw / 512
+	}
+	if sh > 512 {
+		stepY = sh / 512
+	}
+	for y := 0; y < sh; y += stepY {
+		cy := y * h / sh
+		for x := 0; x < sw; x += stepX {
+			cx := x * w / sw
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			sums[cy*w+cx] += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			counts[cy*w+cx]++
+		}
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= counts[i]
+		}
+	}
+	return sums
+}
+
+// dct2D returns the type-II DCT of the n x n matrix pixels
+func dct2D(pixels []float64, n int) []float64 {
+	var (
+		cos  = make([]float64, n*n)
+		rows = make([]float64, n*n)
+		out  = make([]float64, n*n)
+	)
+	for u := 0; u < n; u++ {
+		for x := 0; x < n; x++ {
+			cos[u*n+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*n))
+		}
+	}
+	// transform rows, then columns
+	for y := 0; y < n; y++ {
+		for u := 0; u < n; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += pixels[y*n+x] * cos[u*n+x]
+			}
+			rows[y*n+u] = sum
+		}
+	}
+	for u := 0; u < n; u++ {
+		for v := 0; v < n; v++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y*n+u] * cos[v*n+y]
+			}
+			out[v*n+u] = sum
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package imagehash
+
+import "sync"
+
+// DefaultIndexCapacity is the default max number of hashes kept by Index
+const DefaultIndexCapacity = 10000
+
+type (
+	// Index keeps the hashes of images and a value of each, such as the verdict of the image.
+	// The oldest hash is evicted when the capacity is reached
+	Index struct {
+		mu       sync.RWMutex
+		kind     Kind
+		capacity int
+		entries  []indexEntry
+		next     int
+	}
+
+	indexEntry struct {
+		scope string
+		hash  Hash
+		value string
+	}
+)
+
+// NewIndex is an initializer for an Index of the hashes computed by kind,
+// DefaultIndexCapacity is used if capacity is not positive
+func NewIndex(kind Kind, capacity int) *Index {
+	if capacity <= 0 {
+		capacity = DefaultIndexCapacity
+	}
+	return &Index{kind: kind, capacity: capacity}
+}
+
+// Kind returns the algorithm of the hashes in the index
+func (idx *Index) Kind() Kind {
+	return idx.kind
+}
+
+// Add saves hash and its value in scope, only the hashes in the same scope are compared
+func (idx *Index) Add(scope string, hash Hash, value string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry := indexEntry{scope: scope, hash: hash, value: value}
+	if len(idx.entries) < idx.capacity {
+		idx.entries = append(idx.entries, entry)
+		return
+	}
+	idx.entries[idx.next] = entry
+	idx.next = (idx.next + 1) % idx.capacity
+}
+
+// Nearest returns the value of the hash in scope closest to hash within maxDistance
+func (idx *Index) Nearest(scope string, hash Hash, maxDistance int) (value string, distance int, ok bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	distance = maxDistance + 1
+	for _, entry := range idx.entries {
+		if entry.scope != scope {
+			continue
+		}
+		if d := Distance(entry.hash, hash); d < distance {
+			value, distance, ok = entry.value, d, true
+			if d == 0 {
+				break
+			}
+		}
+	}
+	return
+}
+
+// Len returns the number of hashes in the index
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
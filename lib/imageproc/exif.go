@@ -0,0 +1,135 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+const (
+	jpegMarkerSOI  = 0xD8
+	jpegMarkerSOS  = 0xDA
+	jpegMarkerAPP1 = 0xE1
+	jpegMarkerCOM  = 0xFE
+
+	exifTagOrientation = 0x0112
+)
+
+// jpegSegments calls fn with the marker and payload of every segment before the image data
+func jpegSegments(buf []byte, fn func(marker byte, payload []byte) bool) {
+	if len(buf) < 4 || buf[0] != 0xFF || buf[1] != jpegMarkerSOI {
+		return
+	}
+	for i := 2; i+4 <= len(buf); {
+		if buf[i] != 0xFF {
+			return
+		}
+		marker := buf[i+1]
+		if marker == jpegMarkerSOS {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(buf[i+2:]))
+		if size < 2 || i+2+size > len(buf) {
+			return
+		}
+		if !fn(marker, buf[i+4:i+2+size]) {
+			return
+		}
+		i += 2 + size
+	}
+}
+
+// jpegHasMetadata reports whether buf has EXIF, XMP, IPTC or comment segments
+func jpegHasMetadata(buf []byte) bool {
+	found := false
+	jpegSegments(buf, func(marker byte, _ []byte) bool {
+		// APP1 is EXIF or XMP, APP13 is IPTC
+		if marker == jpegMarkerAPP1 || marker == 0xED || marker == jpegMarkerCOM {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// jpegOrientation returns the EXIF orientation of buf, 1 if it's absent
+func jpegOrientation(buf []byte) int {
+	orientation := 1
+	jpegSegments(buf, func(marker byte, payload []byte) bool {
+		if marker != jpegMarkerAPP1 || len(payload) < 14 || string(payload[:6]) != "Exif\x00\x00" {
+			return true
+		}
+		tiff := payload[6:]
+		var order binary.ByteOrder
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return false
+		}
+
+		// the orientation tag is in IFD0
+		ifd := int(order.Uint32(tiff[4:]))
+		if ifd+2 > len(tiff) {
+			return false
+		}
+		count := int(order.Uint16(tiff[ifd:]))
+		for i := 0; i < count; i++ {
+			entry := ifd + 2 + i*12
+			if entry+12 > len(tiff) {
+				break
+			}
+			if order.Uint16(tiff[entry:]) == exifTagOrientation {
+				if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+					orientation = v
+				}
+				break
+			}
+		}
+		return false
+	})
+	return orientation
+}
+
+// applyOrientation rotates and flips img to the upright position of the EXIF orientation,
+// the orientation is lost with the stripped EXIF, so the pixels must be transformed
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	var (
+		src    = toNRGBA(img)
+		w, h   = src.Rect.Dx(), src.Rect.Dy()
+		dw, dh = w, h
+	)
+	// orientations 5 to 8 swap width and height
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
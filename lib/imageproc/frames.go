@@ -0,0 +1,180 @@
+package imageproc
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"time"
+
+	tupuimagehash "github.com/tuputech/tupu-go-sdk/lib/imagehash"
+)
+
+const (
+	// DefaultFrameInterval is the default play time between two sampled frames
+	DefaultFrameInterval = time.Second
+	// DefaultMaxFrames is the default max number of sampled frames of a GIF
+	DefaultMaxFrames = 10
+
+	// defaultFrameDelay is the delay of a frame without delay, the same as browsers
+	defaultFrameDelay = 100 * time.Millisecond
+)
+
+type (
+	// FrameSampler samples the frames of animated GIFs by interval or scene change
+	FrameSampler struct {
+		interval       time.Duration
+		sceneThreshold int
+		maxFrames      int
+		quality        int
+	}
+
+	// FrameOptFunc is a function to set the option of FrameSampler
+	FrameOptFunc func(*FrameSampler)
+
+	// Frame is a sampled frame of a GIF encoded as JPEG
+	Frame struct {
+		// Index is the index of the frame in the GIF
+		Index int
+		// Offset is the play time of the frame from the start of the GIF
+		Offset time.Duration
+		Data   []byte
+	}
+)
+
+// WithFrameInterval sets the min play time between two sampled frames, 0 samples every frame
+func WithFrameInterval(interval time.Duration) FrameOptFunc {
+	return func(s *FrameSampler) {
+		s.interval = interval
+	}
+}
+
+// WithSceneChange sets the dHash distance to the last sampled frame from which a frame is
+// sampled before the interval passes, 0 disables it
+func WithSceneChange(threshold int) FrameOptFunc {
+	return func(s *FrameSampler) {
+		s.sceneThreshold = threshold
+	}
+}
+
+// WithMaxFrames sets the max number of sampled frames, the frames are thinned out evenly if exceeded
+func WithMaxFrames(n int) FrameOptFunc {
+	return func(s *FrameSampler) {
+		if n > 0 {
+			s.maxFrames = n
+		}
+	}
+}
+
+// WithFrameQuality sets the JPEG quality of the sampled frames
+func WithFrameQuality(quality int) FrameOptFunc {
+	return func(s *FrameSampler) {
+		if quality > 0 && quality <= 100 {
+			s.quality = quality
+		}
+	}
+}
+
+// NewFrameSampler is an initializer for a FrameSampler
+func NewFrameSampler(opts ...FrameOptFunc) *FrameSampler {
+	s := &FrameSampler{
+		interval:  DefaultFrameInterval,
+		maxFrames: DefaultMaxFrames,
+		quality:   DefaultQuality,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sample returns the sampled frames of an animated GIF, nil is returned if buf is not an animated GIF
+func (s *FrameSampler) Sample(buf []byte) ([]Frame, error) {
+	if !bytes.HasPrefix(buf, []byte("GIF8")) {
+		return nil, nil
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) <= 1 {
+		return nil, nil
+	}
+
+	var (
+		canvas   = image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+		selected []Frame
+		images   []image.Image
+		lastHash tupuimagehash.Hash
+		lastAt   time.Duration
+		offset   time.Duration
+	)
+	if canvas.Rect.Empty() {
+		canvas = image.NewRGBA(g.Image[0].Bounds().Union(image.Rect(0, 0, 1, 1)))
+	}
+
+	// step1. compose every frame and select the frames by interval or scene change
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Rect)
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		pick := i == 0 || s.interval <= 0 && s.sceneThreshold <= 0 ||
+			(s.interval > 0 && offset-lastAt >= s.interval)
+		hash := tupuimagehash.Hash(0)
+		if s.sceneThreshold > 0 {
+			hash = tupuimagehash.DifferenceHash(canvas)
+			pick = pick || tupuimagehash.Distance(hash, lastHash) >= s.sceneThreshold
+		}
+		if pick {
+			snapshot := image.NewRGBA(canvas.Rect)
+			copy(snapshot.Pix, canvas.Pix)
+			selected = append(selected, Frame{Index: i, Offset: offset})
+			images = append(images, snapshot)
+			lastHash, lastAt = hash, offset
+		}
+
+		// dispose the frame before drawing the next one
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				canvas = previous
+			}
+		}
+		delay := defaultFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		offset += delay
+	}
+
+	// step2. thin out the frames evenly, the first and last ones are kept
+	if s.maxFrames > 0 && len(selected) > s.maxFrames {
+		var (
+			thinFrames = make([]Frame, 0, s.maxFrames)
+			thinImages = make([]image.Image, 0, s.maxFrames)
+		)
+		for k := 0; k < s.maxFrames; k++ {
+			idx := 0
+			if s.maxFrames > 1 {
+				idx = k * (len(selected) - 1) / (s.maxFrames - 1)
+			}
+			thinFrames = append(thinFrames, selected[idx])
+			thinImages = append(thinImages, images[idx])
+		}
+		selected, images = thinFrames, thinImages
+	}
+
+	// step3. encode the frames as JPEG
+	for k := range selected {
+		if selected[k].Data, err = encodeJPEG(images[k], s.quality); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
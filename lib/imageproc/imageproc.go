@@ -0,0 +1,234 @@
+// Package imageproc provide preprocessing of images before uploading, large images are
+// downscaled and re-encoded, metadata is stripped and invalid images are rejected locally
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultMaxDimension is the default max width and height of the processed image
+	DefaultMaxDimension = 2048
+	// DefaultQuality is the default JPEG quality of the re-encoded image
+	DefaultQuality = 85
+	// DefaultMaxBytes is the default max size of the processed image
+	DefaultMaxBytes = 5 << 20
+	// DefaultMaxPixels is the default max number of pixels of an image to decode, about 256MB in RGBA
+	DefaultMaxPixels = 64 << 20
+
+	// minQuality is the lowest quality tried to fit the image into the max size
+	minQuality = 40
+)
+
+var (
+	// ErrUnsupportedFormat is returned when the image can't be decoded
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrImageTooLarge is returned when the image is still larger than the max size after processing
+	ErrImageTooLarge = errors.New("image too large")
+	// ErrImageTooSmall is returned when the width or height of the image is less than the min dimension
+	ErrImageTooSmall = errors.New("image too small")
+)
+
+type (
+	// Processor downscales and re-encodes images, the zero value is not usable, use NewProcessor
+	Processor struct {
+		maxDimension  int
+		minDimension  int
+		quality       int
+		maxBytes      int
+		maxPixels     int64
+		stripMetadata bool
+		formats       map[string]bool
+	}
+
+	// ProcOptFunc is a function to set the option of Processor
+	ProcOptFunc func(*Processor)
+)
+
+// WithMaxDimension sets the max width and height, the larger images are downscaled keeping the aspect ratio
+func WithMaxDimension(n int) ProcOptFunc {
+	return func(p *Processor) {
+		p.maxDimension = n
+	}
+}
+
+// WithMinDimension sets the min width and height, the smaller images are rejected with ErrImageTooSmall
+func WithMinDimension(n int) ProcOptFunc {
+	return func(p *Processor) {
+		p.minDimension = n
+	}
+}
+
+// WithQuality sets the JPEG quality of the re-encoded images, from 1 to 100
+func WithQuality(quality int) ProcOptFunc {
+	return func(p *Processor) {
+		if quality > 0 && quality <= 100 {
+			p.quality = quality
+		}
+	}
+}
+
+// WithMaxBytes sets the max size of the processed images, 0 means no limit
+func WithMaxBytes(n int) ProcOptFunc {
+	return func(p *Processor) {
+		p.maxBytes = n
+	}
+}
+
+// WithMaxPixels sets the max width*height of the images, the larger images are rejected with
+// ErrImageTooLarge before decoding, so a small file can't exhaust the memory, 0 means no limit
+func WithMaxPixels(n int64) ProcOptFunc {
+	return func(p *Processor) {
+		p.maxPixels = n
+	}
+}
+
+// WithStripMetadata sets whether the EXIF and other metadata of JPEG images are stripped, default true
+func WithStripMetadata(strip bool) ProcOptFunc {
+	return func(p *Processor) {
+		p.stripMetadata = strip
+	}
+}
+
+// WithFormats sets the formats uploaded as is, the images in other formats are converted
+// to JPEG, default "jpeg", "png" and "gif". The decoders of other formats can be added
+// with image.RegisterFormat
+func WithFormats(formats ...string) ProcOptFunc {
+	return func(p *Processor) {
+		p.formats = make(map[string]bool, len(formats))
+		for _, f := range formats {
+			p.formats[strings.ToLower(f)] = true
+		}
+	}
+}
+
+// NewProcessor is an initializer for a Processor
+func NewProcessor(opts ...ProcOptFunc) *Processor {
+	p := &Processor{
+		maxDimension:  DefaultMaxDimension,
+		quality:       DefaultQuality,
+		maxBytes:      DefaultMaxBytes,
+		maxPixels:     DefaultMaxPixels,
+		stripMetadata: true,
+		formats:       map[string]bool{"jpeg": true, "png": true, "gif": true},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Process returns the processed image and its file name, the extension of the name is
+// changed if the image is converted. buf is returned as is if nothing needs to be done
+func (p *Processor) Process(buf []byte, name string) ([]byte, string, error) {
+	// step1. validate the format and size without decoding the pixels
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(buf))
+	if err != nil {
+		return nil, name, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if p.minDimension > 0 && (cfg.Width < p.minDimension || cfg.Height < p.minDimension) {
+		return nil, name, fmt.Errorf("%w: %dx%d", ErrImageTooSmall, cfg.Width, cfg.Height)
+	}
+	if p.maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > p.maxPixels {
+		return nil, name, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	var (
+		orientation = 1
+		oversized   = p.maxDimension > 0 && (cfg.Width > p.maxDimension || cfg.Height > p.maxDimension)
+		heavy       = p.maxBytes > 0 && len(buf) > p.maxBytes
+		converted   = !p.formats[format]
+		withMeta    = false
+	)
+	if format == "jpeg" {
+		orientation = jpegOrientation(buf)
+		withMeta = p.stripMetadata && jpegHasMetadata(buf)
+	}
+	if !oversized && !heavy && !converted && !withMeta {
+		return buf, name, nil
+	}
+	// an animated GIF would lose its frames, it is never flattened and a too large one is rejected
+	if format == "gif" && isAnimatedGIF(buf) {
+		if heavy {
+			return nil, name, fmt.Errorf("%w: animated gif of %d bytes", ErrImageTooLarge, len(buf))
+		}
+		return buf, name, nil
+	}
+
+	// step2. decode, rotate by the EXIF orientation and downscale
+	img, _, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, name, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	img = applyOrientation(img, orientation)
+	if p.maxDimension > 0 {
+		img = Downscale(img, p.maxDimension)
+	}
+
+	// step3. re-encode, PNG and GIF are kept lossless unless they are too large
+	outFormat := format
+	if converted || (heavy && format != "jpeg") {
+		outFormat = "jpeg"
+	}
+	out, err := p.encode(img, outFormat)
+	if err != nil {
+		return nil, name, err
+	}
+	if outFormat != "jpeg" && p.maxBytes > 0 && len(out) > p.maxBytes {
+		outFormat = "jpeg"
+		if out, err = p.encode(img, outFormat); err != nil {
+			return nil, name, err
+		}
+	}
+	for quality := p.quality - 10; outFormat == "jpeg" && p.maxBytes > 0 && len(out) > p.maxBytes && quality >= minQuality; quality -= 10 {
+		if out, err = encodeJPEG(img, quality); err != nil {
+			return nil, name, err
+		}
+	}
+	if p.maxBytes > 0 && len(out) > p.maxBytes {
+		return nil, name, fmt.Errorf("%w: %d bytes", ErrImageTooLarge, len(out))
+	}
+
+	if outFormat != format {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".jpg"
+	}
+	return out, name, nil
+}
+
+func (p *Processor) encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	case "gif":
+		if err := gif.Encode(&buf, img, nil); err != nil {
+			return nil, err
+		}
+	default:
+		return encodeJPEG(img, p.quality)
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isAnimatedGIF(buf []byte) bool {
+	g, err := gif.DecodeAll(bytes.NewReader(buf))
+	return err == nil && len(g.Image) > 1
+}
@@ -0,0 +1,89 @@
+package imageproc
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Downscale scales img to fit in maxDimension x maxDimension keeping the aspect ratio, every
+// pixel is the average of the source pixels it covers. img is returned if it is small enough
+func Downscale(img image.Image, maxDimension int) image.Image {
+	var (
+		bounds = img.Bounds()
+		sw, sh = bounds.Dx(), bounds.Dy()
+	)
+	if maxDimension <= 0 || (sw <= maxDimension && sh <= maxDimension) {
+		return img
+	}
+
+	dw, dh := maxDimension, sh*maxDimension/sw
+	if sh > sw {
+		dw, dh = sw*maxDimension/sh, maxDimension
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	src := toNRGBA(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				off := y*src.Stride + x0*4
+				for x := x0; x < x1; x++ {
+					pa := uint64(src.Pix[off+3])
+					// weight the colors by alpha, so transparent pixels don't darken the edges
+					r += uint64(src.Pix[off]) * pa
+					g += uint64(src.Pix[off+1]) * pa
+					b += uint64(src.Pix[off+2]) * pa
+					a += pa
+					n++
+					off += 4
+				}
+			}
+			i := dst.PixOffset(dx, dy)
+			if a > 0 {
+				dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// toNRGBA converts img to *image.NRGBA with the origin at (0, 0)
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// flatten composes img over a white background, JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
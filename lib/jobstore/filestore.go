@@ -0,0 +1,221 @@
+package jobstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	opPut    = "put"
+	opDelete = "delete"
+
+	// compact the log on open when it has more entries than records * compactRatio
+	compactRatio = 4
+)
+
+type (
+	// FileStore is a Store backed by an append-only log file, every change is appended
+	// as a json line and the log is replayed into memory when the store is opened
+	FileStore struct {
+		mu      sync.Mutex
+		path    string
+		file    *os.File
+		entries int
+		records map[string]*Record
+		// SyncWrite calls fsync after every change if it is true
+		SyncWrite bool
+	}
+
+	logEntry struct {
+		Op     string  `json:"op"`
+		Kind   string  `json:"kind,omitempty"`
+		ID     string  `json:"id,omitempty"`
+		Record *Record `json:"record,omitempty"`
+	}
+)
+
+// OpenFileStore opens or creates the log file at path
+func OpenFileStore(path string) (*FileStore, error) {
+	if tupuerror.StringIsEmpty(path) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	fs := &FileStore{
+		path:    path,
+		records: make(map[string]*Record),
+	}
+	if err := fs.replay(); err != nil {
+		return nil, err
+	}
+	if fs.entries > compactRatio*len(fs.records) {
+		if err := fs.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fs.file = file
+	return fs, nil
+}
+
+// Put implements Store
+func (fs *FileStore) Put(rec *Record) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	rec = rec.Clone()
+	if err := fs.append(&logEntry{Op: opPut, Record: rec}); err != nil {
+		return err
+	}
+	fs.records[recordKey(rec.Kind, rec.ID)] = rec
+	return nil
+}
+
+// Get implements Store
+func (fs *FileStore) Get(kind, id string) (*Record, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	rec, ok := fs.records[recordKey(kind, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return rec.Clone(), nil
+}
+
+// List implements Store
+func (fs *FileStore) List(kind string) ([]*Record, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return listRecords(fs.records, kind), nil
+}
+
+// Delete implements Store
+func (fs *FileStore) Delete(kind, id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	key := recordKey(kind, id)
+	if _, ok := fs.records[key]; !ok {
+		return nil
+	}
+	if err := fs.append(&logEntry{Op: opDelete, Kind: kind, ID: id}); err != nil {
+		return err
+	}
+	delete(fs.records, key)
+	return nil
+}
+
+// Compact rewrites the log file with only the current records
+func (fs *FileStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.file.Close(); err != nil {
+		return err
+	}
+	err := fs.compact()
+	// reopen the log even if compaction failed, the old file is still in place
+	file, e := os.OpenFile(fs.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if e != nil {
+		return e
+	}
+	fs.file = file
+	return err
+}
+
+// Close implements Store
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.file.Close()
+}
+
+func (fs *FileStore) append(entry *logEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = fs.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	fs.entries++
+	if fs.SyncWrite {
+		return fs.file.Sync()
+	}
+	return nil
+}
+
+func (fs *FileStore) replay() error {
+	file, err := os.Open(fs.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry logEntry
+			// a broken line is left by a crash while writing, it is skipped
+			if json.Unmarshal(line, &entry) == nil {
+				fs.apply(&entry)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (fs *FileStore) apply(entry *logEntry) {
+	fs.entries++
+	switch entry.Op {
+	case opPut:
+		if entry.Record != nil {
+			fs.records[recordKey(entry.Record.Kind, entry.Record.ID)] = entry.Record
+		}
+	case opDelete:
+		delete(fs.records, recordKey(entry.Kind, entry.ID))
+	}
+}
+
+func (fs *FileStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for _, rec := range listRecords(fs.records, "") {
+		line, _ := json.Marshal(&logEntry{Op: opPut, Record: rec})
+		writer.Write(append(line, '\n'))
+	}
+	if err = writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), fs.path); err != nil {
+		return err
+	}
+	fs.entries = len(fs.records)
+	return nil
+}
@@ -0,0 +1,207 @@
+// Package jobstore provide persistent storage of TUPU async recognition jobs,
+// so in-flight jobs can be recovered after the process restarts
+package jobstore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// KindVideoAsync is the kind of video async job, the ID is videoId
+	KindVideoAsync = "videoasync"
+	// KindSpeechStream is the kind of speech stream session, the ID is requestId
+	KindSpeechStream = "speechstream"
+
+	// StateSubmitted means the job is accepted by TUPU
+	StateSubmitted = "submitted"
+	// StateRunning means a partial result of the job has been received
+	StateRunning = "running"
+	// StateFinished means the final result of the job has been received
+	StateFinished = "finished"
+	// StateCancelled means the job is closed by caller
+	StateCancelled = "cancelled"
+	// StateFailed means the job is over without final result
+	StateFailed = "failed"
+)
+
+// ErrNotFound is returned when the record doesn't exist
+var ErrNotFound = errors.New("job record not found")
+
+type (
+	// Record is the persistent state of a job
+	Record struct {
+		Kind     string `json:"kind"`
+		ID       string `json:"id"`
+		SecretID string `json:"secretId"`
+		// Source is the url of the video or stream
+		Source string `json:"source,omitempty"`
+		State  string `json:"state"`
+		// Callbacks is the number of callbacks received
+		Callbacks int `json:"callbacks,omitempty"`
+		// LastResult is the latest result received by callback or query
+		LastResult string `json:"lastResult,omitempty"`
+		// Result is the final result of the job
+		Result    string            `json:"result,omitempty"`
+		Extra     map[string]string `json:"extra,omitempty"`
+		CreatedAt time.Time         `json:"createdAt"`
+		UpdatedAt time.Time         `json:"updatedAt"`
+	}
+
+	// Store is the interface to save job records
+	Store interface {
+		// Put creates or replaces the record of rec.Kind and rec.ID
+		Put(rec *Record) error
+		// Get returns a copy of the record, ErrNotFound if it doesn't exist
+		Get(kind, id string) (*Record, error)
+		// List returns the records of kind sorted by CreatedAt, all kinds if kind is empty
+		List(kind string) ([]*Record, error)
+		// Delete removes the record
+		Delete(kind, id string) error
+		// Close releases the resource of the store
+		Close() error
+	}
+
+	// MemoryStore is a Store which only lives in memory
+	MemoryStore struct {
+		mu      sync.RWMutex
+		records map[string]*Record
+	}
+)
+
+// IsOver reports whether the job is finished, cancelled or failed
+func (rec *Record) IsOver() bool {
+	switch rec.State {
+	case StateFinished, StateCancelled, StateFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Clone returns a deep copy of the record
+func (rec *Record) Clone() *Record {
+	c := *rec
+	if rec.Extra != nil {
+		c.Extra = make(map[string]string, len(rec.Extra))
+		for k, v := range rec.Extra {
+			c.Extra[k] = v
+		}
+	}
+	return &c
+}
+
+// Update loads the record, calls fn to change it and puts it back,
+// a new record is created by fn if the record doesn't exist
+func Update(store Store, kind, id string, fn func(rec *Record)) error {
+	rec, err := store.Get(kind, id)
+	if err == ErrNotFound {
+		rec = &Record{Kind: kind, ID: id, CreatedAt: time.Now()}
+	} else if err != nil {
+		return err
+	}
+	fn(rec)
+	rec.UpdatedAt = time.Now()
+	return store.Put(rec)
+}
+
+// Pending returns the records of kind which are not over
+func Pending(store Store, kind string) ([]*Record, error) {
+	records, err := store.List(kind)
+	if err != nil {
+		return nil, err
+	}
+	pending := records[:0]
+	for _, rec := range records {
+		if !rec.IsOver() {
+			pending = append(pending, rec)
+		}
+	}
+	return pending, nil
+}
+
+// Prune deletes the records of kind which are over and not updated in maxAge, the log of a
+// FileStore is compacted after that, the number of deleted records is returned
+func Prune(store Store, kind string, maxAge time.Duration) (int, error) {
+	records, err := store.List(kind)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, rec := range records {
+		if !rec.IsOver() || time.Since(rec.UpdatedAt) < maxAge {
+			continue
+		}
+		if err = store.Delete(rec.Kind, rec.ID); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	if fs, ok := store.(*FileStore); ok && pruned > 0 {
+		err = fs.Compact()
+	}
+	return pruned, err
+}
+
+// NewMemoryStore is an initializer for a MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record)}
+}
+
+// Put implements Store
+func (ms *MemoryStore) Put(rec *Record) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.records[recordKey(rec.Kind, rec.ID)] = rec.Clone()
+	return nil
+}
+
+// Get implements Store
+func (ms *MemoryStore) Get(kind, id string) (*Record, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	rec, ok := ms.records[recordKey(kind, id)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return rec.Clone(), nil
+}
+
+// List implements Store
+func (ms *MemoryStore) List(kind string) ([]*Record, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return listRecords(ms.records, kind), nil
+}
+
+// Delete implements Store
+func (ms *MemoryStore) Delete(kind, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.records, recordKey(kind, id))
+	return nil
+}
+
+// Close implements Store
+func (ms *MemoryStore) Close() error {
+	return nil
+}
+
+func recordKey(kind, id string) string {
+	return kind + "/" + id
+}
+
+func listRecords(records map[string]*Record, kind string) []*Record {
+	list := make([]*Record, 0, len(records))
+	for _, rec := range records {
+		if len(kind) == 0 || rec.Kind == kind {
+			list = append(list, rec.Clone())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
@@ -0,0 +1,157 @@
+// Package mediatype provide detection of media formats by the magic bytes of file headers,
+// and validation of the formats and sizes accepted by TUPU recognition APIs
+package mediatype
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// SniffLen is the number of header bytes used by Detect
+const SniffLen = 512
+
+const (
+	// KindImage is the kind of image formats
+	KindImage = "image"
+	// KindAudio is the kind of audio formats
+	KindAudio = "audio"
+	// KindVideo is the kind of video formats
+	KindVideo = "video"
+	// KindPlaylist is the kind of stream playlists
+	KindPlaylist = "playlist"
+)
+
+// Type is a media format detected from the file header
+type Type struct {
+	Kind string
+	// Format is the short name of the format, such as "jpeg", "mp3" or "mp4"
+	Format string
+	// MIME is the MIME type of the format
+	MIME string
+}
+
+// Unknown is returned by Detect if the format isn't recognized
+var Unknown = Type{}
+
+// String returns the format name
+func (t Type) String() string {
+	if len(t.Format) == 0 {
+		return "unknown"
+	}
+	return t.Format
+}
+
+// IsUnknown reports whether t is Unknown
+func (t Type) IsUnknown() bool {
+	return len(t.Format) == 0
+}
+
+var asfGUID = []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11, 0xA6, 0xD9, 0x00, 0xAA, 0x00, 0x62, 0xCE, 0x6C}
+
+// Detect returns the format of the file starting with header, SniffLen bytes are enough
+func Detect(header []byte) Type {
+	h := header
+	switch {
+	// images
+	case bytes.HasPrefix(h, []byte{0xFF, 0xD8, 0xFF}):
+		return Type{KindImage, "jpeg", "image/jpeg"}
+	case bytes.HasPrefix(h, []byte("\x89PNG\r\n\x1a\n")):
+		return Type{KindImage, "png", "image/png"}
+	case bytes.HasPrefix(h, []byte("GIF87a")), bytes.HasPrefix(h, []byte("GIF89a")):
+		return Type{KindImage, "gif", "image/gif"}
+	case bytes.HasPrefix(h, []byte("BM")) && len(h) >= 14:
+		return Type{KindImage, "bmp", "image/bmp"}
+	case isRIFF(h, "WEBP"):
+		return Type{KindImage, "webp", "image/webp"}
+	case bytes.HasPrefix(h, []byte("II*\x00")), bytes.HasPrefix(h, []byte("MM\x00*")):
+		return Type{KindImage, "tiff", "image/tiff"}
+
+	// audio
+	case bytes.HasPrefix(h, []byte("#!AMR")):
+		return Type{KindAudio, "amr", "audio/amr"}
+	case isRIFF(h, "WAVE"):
+		return Type{KindAudio, "wav", "audio/wav"}
+	case bytes.HasPrefix(h, []byte("ID3")):
+		return Type{KindAudio, "mp3", "audio/mpeg"}
+	case bytes.HasPrefix(h, []byte("fLaC")):
+		return Type{KindAudio, "flac", "audio/flac"}
+	case bytes.HasPrefix(h, []byte("OggS")):
+		return Type{KindAudio, "ogg", "audio/ogg"}
+	case len(h) >= 2 && h[0] == 0xFF && h[1]&0xF6 == 0xF0:
+		// ADTS, the layer bits are 00
+		return Type{KindAudio, "aac", "audio/aac"}
+	case len(h) >= 3 && h[0] == 0xFF && h[1]&0xE0 == 0xE0 && (h[1]>>1)&0x03 != 0 && h[2]&0xF0 != 0xF0:
+		// MPEG audio frame without ID3 tag
+		return Type{KindAudio, "mp3", "audio/mpeg"}
+
+	// video
+	case bytes.HasPrefix(h, []byte("FLV")):
+		return Type{KindVideo, "flv", "video/x-flv"}
+	case bytes.HasPrefix(h, asfGUID):
+		// ASF is the container of both WMV and WMA
+		return Type{KindVideo, "wmv", "video/x-ms-asf"}
+	case isRIFF(h, "AVI "):
+		return Type{KindVideo, "avi", "video/x-msvideo"}
+	case bytes.HasPrefix(h, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		if bytes.Contains(h, []byte("webm")) {
+			return Type{KindVideo, "webm", "video/webm"}
+		}
+		return Type{KindVideo, "mkv", "video/x-matroska"}
+	case bytes.HasPrefix(h, []byte(".RMF")):
+		return Type{KindVideo, "rmvb", "application/vnd.rn-realmedia"}
+	case bytes.HasPrefix(h, []byte{0x06, 0x0E, 0x2B, 0x34, 0x02, 0x05, 0x01, 0x01}):
+		return Type{KindVideo, "mxf", "application/mxf"}
+	case bytes.HasPrefix(h, []byte{0x00, 0x00, 0x01, 0xBA}), bytes.HasPrefix(h, []byte{0x00, 0x00, 0x01, 0xB3}):
+		return Type{KindVideo, "mpeg", "video/mpeg"}
+	case isTransportStream(h):
+		return Type{KindVideo, "ts", "video/mp2t"}
+	case len(h) >= 12 && string(h[4:8]) == "ftyp":
+		return detectISOBMFF(h)
+	case len(h) >= 8 && (string(h[4:8]) == "moov" || string(h[4:8]) == "mdat" || string(h[4:8]) == "wide"):
+		return Type{KindVideo, "mov", "video/quicktime"}
+
+	// playlists
+	case bytes.HasPrefix(bytes.TrimPrefix(h, []byte("\xEF\xBB\xBF")), []byte("#EXTM3U")):
+		return Type{KindPlaylist, "m3u8", "application/vnd.apple.mpegurl"}
+	}
+	return Unknown
+}
+
+func isRIFF(h []byte, form string) bool {
+	return len(h) >= 12 && string(h[:4]) == "RIFF" && string(h[8:12]) == form
+}
+
+// isTransportStream checks the sync byte of the first packets, 188 bytes each
+func isTransportStream(h []byte) bool {
+	if len(h) < 1 || h[0] != 0x47 {
+		return false
+	}
+	for i := 188; i < len(h); i += 188 {
+		if h[i] != 0x47 {
+			return false
+		}
+	}
+	return len(h) > 188
+}
+
+// detectISOBMFF tells the formats of the ISO base media file by the major brand
+func detectISOBMFF(h []byte) Type {
+	brand := string(h[8:12])
+	switch {
+	case brand == "qt  ":
+		return Type{KindVideo, "mov", "video/quicktime"}
+	case brand[:3] == "3gp" || brand[:3] == "3g2":
+		return Type{KindVideo, "3gp", "video/3gpp"}
+	case brand == "M4A " || brand == "M4B ":
+		return Type{KindAudio, "m4a", "audio/mp4"}
+	case brand == "heic" || brand == "heix" || brand == "mif1" || brand == "msf1":
+		return Type{KindImage, "heic", "image/heic"}
+	case brand == "avif":
+		return Type{KindImage, "avif", "image/avif"}
+	}
+	// the size of the ftyp box, only used to make sure the header is sane
+	if size := binary.BigEndian.Uint32(h[:4]); size < 8 {
+		return Unknown
+	}
+	return Type{KindVideo, "mp4", "video/mp4"}
+}
@@ -0,0 +1,156 @@
+package mediatype
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+var (
+	// ErrEmpty is returned when the file has no content
+	ErrEmpty = errors.New("empty file")
+	// ErrUnknownFormat is returned when the format can't be detected from the file header
+	ErrUnknownFormat = errors.New("unknown media format")
+	// ErrFormatNotAllowed is returned when the format isn't accepted by the API
+	ErrFormatNotAllowed = errors.New("media format not allowed")
+	// ErrTooLarge is returned when the file is larger than the limit of the API
+	ErrTooLarge = errors.New("media file too large")
+)
+
+var (
+	// ImageRule is the formats and size accepted by image recognition
+	ImageRule = &Rule{
+		Name:     "image",
+		Formats:  []string{"jpeg", "png", "gif", "bmp", "webp", "tiff"},
+		MaxBytes: 10 << 20,
+	}
+	// SpeechSyncRule is the formats and size accepted by speech sync recognition
+	SpeechSyncRule = &Rule{
+		Name:     "speechsync",
+		Formats:  []string{"amr", "mp3", "wmv", "wav", "flv"},
+		MaxBytes: 20 << 20,
+	}
+	// VideoSyncRule is the formats and size accepted by video sync recognition
+	VideoSyncRule = &Rule{
+		Name:     "videosync",
+		Formats:  []string{"mkv", "mp4", "wmv", "rmvb", "flv", "3gp", "ts", "mov", "gif", "m3u8", "mpeg", "mxf"},
+		MaxBytes: 200 << 20,
+	}
+)
+
+type (
+	// Rule is the formats and size accepted by an API
+	Rule struct {
+		Name string
+		// Formats is the allowed Type.Format, any detected format is allowed if it's empty
+		Formats []string
+		// MaxBytes is the max size of a file, 0 means no limit
+		MaxBytes int64
+	}
+
+	// ValidationError describes the file rejected by a Rule, it wraps one of ErrEmpty,
+	// ErrUnknownFormat, ErrFormatNotAllowed and ErrTooLarge
+	ValidationError struct {
+		Rule string
+		File string
+		Type Type
+		Size int64
+		Err  error
+	}
+)
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	switch e.Err {
+	case ErrFormatNotAllowed:
+		return fmt.Sprintf("%s: %s: format %s is not allowed", e.Rule, e.File, e.Type)
+	case ErrTooLarge:
+		return fmt.Sprintf("%s: %s: %d bytes exceeds the limit", e.Rule, e.File, e.Size)
+	default:
+		return fmt.Sprintf("%s: %s: %v", e.Rule, e.File, e.Err)
+	}
+}
+
+// Unwrap returns the cause of the error, for errors.Is
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Allows reports whether format is allowed by the rule
+func (r *Rule) Allows(format string) bool {
+	if len(r.Formats) == 0 {
+		return len(format) > 0
+	}
+	for _, f := range r.Formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate detects the format of buf, and checks it and the size against the rule
+func (r *Rule) Validate(buf []byte, name string) (Type, error) {
+	header := buf
+	if len(header) > SniffLen {
+		header = header[:SniffLen]
+	}
+	return r.check(header, int64(len(buf)), name)
+}
+
+// ValidateFile is the same as Validate, but only the header of the file is read
+func (r *Rule) ValidateFile(path string) (Type, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Unknown, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Unknown, err
+	}
+	header := make([]byte, SniffLen)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Unknown, err
+	}
+	return r.check(header[:n], info.Size(), filepath.Base(path))
+}
+
+// ValidateDataInfo validates the local or binary content of dataInfo, a remote file is not checked
+func (r *Rule) ValidateDataInfo(dataInfo *tupumodel.DataInfo) (Type, error) {
+	switch {
+	case len(dataInfo.RemoteInfo) > 0:
+		return Unknown, nil
+	case len(dataInfo.Path) > 0:
+		return r.ValidateFile(dataInfo.Path)
+	case dataInfo.Buf != nil:
+		// Bytes doesn't consume the buffer
+		return r.Validate(dataInfo.Buf.Bytes(), dataInfo.FileName)
+	default:
+		return Unknown, &ValidationError{Rule: r.Name, File: dataInfo.FileName, Err: ErrEmpty}
+	}
+}
+
+func (r *Rule) check(header []byte, size int64, name string) (Type, error) {
+	if size == 0 {
+		return Unknown, &ValidationError{Rule: r.Name, File: name, Err: ErrEmpty}
+	}
+	t := Detect(header)
+	if t.IsUnknown() {
+		return t, &ValidationError{Rule: r.Name, File: name, Size: size, Err: ErrUnknownFormat}
+	}
+	if !r.Allows(t.Format) {
+		return t, &ValidationError{Rule: r.Name, File: name, Type: t, Size: size, Err: ErrFormatNotAllowed}
+	}
+	if r.MaxBytes > 0 && size > r.MaxBytes {
+		return t, &ValidationError{Rule: r.Name, File: name, Type: t, Size: size, Err: ErrTooLarge}
+	}
+	return t, nil
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// fileListKey is the key of the results of every file in a task
+const fileListKey = "fileList"
+
+// ErrNotSplittable is returned when the fileList of a task doesn't match the files of the request
+var ErrNotSplittable = errors.New("result can't be split by file")
+
+// ItemResult is the results of one file in a batch, key is the task id and value
+// is the element of the task's fileList
+type ItemResult map[string]json.RawMessage
+
+// SplitResult splits the fileList of every task in result into the results of n files,
+// ErrNotSplittable is returned if the result isn't successful or any fileList has not n elements
+func SplitResult(result string, n int) ([]ItemResult, error) {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return nil, fmt.Errorf("invalid result: %v", err)
+	}
+	var code int
+	if err := json.Unmarshal(data["code"], &code); err != nil || code != 0 {
+		return nil, ErrNotSplittable
+	}
+
+	items := make([]ItemResult, n)
+	for i := range items {
+		items[i] = make(ItemResult)
+	}
+	for key, val := range data {
+		var task map[string]json.RawMessage
+		if json.Unmarshal(val, &task) != nil {
+			continue
+		}
+		var fileList []json.RawMessage
+		if json.Unmarshal(task[fileListKey], &fileList) != nil {
+			continue
+		}
+		if len(fileList) != n {
+			return nil, ErrNotSplittable
+		}
+		for i, file := range fileList {
+			items[i][key] = file
+		}
+	}
+	return items, nil
+}
+
+// MergeResult rebuilds the fileList of every task from items in order. The other fields of
+// base are kept, a successful result is created if base is empty
+func MergeResult(base string, items []ItemResult) (string, error) {
+	data := make(map[string]json.RawMessage)
+	if len(base) > 0 {
+		if err := json.Unmarshal([]byte(base), &data); err != nil {
+			return "", fmt.Errorf("invalid result: %v", err)
+		}
+	} else {
+		data["code"] = json.RawMessage("0")
+		data["message"] = json.RawMessage(`"success"`)
+		data["timestamp"] = json.RawMessage(fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond)))
+	}
+
+	// collect the fileList of every task in order
+	var (
+		taskIDs   []string
+		fileLists = make(map[string][]json.RawMessage)
+	)
+	for _, item := range items {
+		for taskID := range item {
+			if _, ok := fileLists[taskID]; !ok {
+				taskIDs = append(taskIDs, taskID)
+				fileLists[taskID] = nil
+			}
+		}
+	}
+	for _, taskID := range taskIDs {
+		for _, item := range items {
+			if file, ok := item[taskID]; ok {
+				fileLists[taskID] = append(fileLists[taskID], file)
+			}
+		}
+	}
+
+	for _, taskID := range taskIDs {
+		task := make(map[string]json.RawMessage)
+		if val, ok := data[taskID]; ok {
+			json.Unmarshal(val, &task)
+		}
+		task[fileListKey], _ = json.Marshal(fileLists[taskID])
+		data[taskID], _ = json.Marshal(task)
+	}
+
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // Result is a wrapper for service result parsed from response
@@ -15,17 +17,6 @@ type Result struct {
 	Others    map[string]interface{}
 }
 
-func parseTaskVal(val interface{}) (v map[string]interface{}, e error) {
-	defer func() {
-		if err := recover(); err != nil {
-			//fmt.Println("Panic info is: ", err)
-			e = fmt.Errorf("%v", e)
-		}
-	}()
-	v = val.(map[string]interface{})
-	return
-}
-
 // ParseResult is a helper to parse json string and create a Result struct
 func ParseResult(s string) *Result {
 
@@ -37,28 +28,80 @@ func ParseResult(s string) *Result {
 	if json.Unmarshal([]byte(s), &data) != nil {
 		return nil
 	}
+	r, err := ParseResultData(data, nil)
+	if err != nil {
+		return nil
+	}
+	return r
+}
 
-	r := Result{}
-	r.Tasks = make(map[string]interface{})
-	r.Others = make(map[string]interface{})
+// ParseResultData is a helper to create a Result struct from a parsed json object, field is called
+// with every key other than the common fields first, and the key is skipped if field returns true,
+// so the api specific fields are not taken as tasks. field can be nil. An error is returned if the
+// code isn't a number or a numeric string, so a malformed result isn't taken as a success
+func ParseResultData(data map[string]interface{}, field func(key string, val interface{}) bool) (*Result, error) {
+	r := &Result{
+		Tasks:  make(map[string]interface{}),
+		Others: make(map[string]interface{}),
+	}
 	for key, val := range data {
 		switch key {
 		case "timestamp":
-			r.Timestamp = int64(val.(float64))
+			r.Timestamp = int64(ToFloat(val))
 		case "nonce":
-			r.Nonce = val.(string)
+			r.Nonce = fmt.Sprint(val)
 		case "code":
-			r.Code = int(val.(float64))
+			code, err := parseCode(val)
+			if err != nil {
+				return nil, err
+			}
+			r.Code = code
 		case "message":
-			r.Message = val.(string)
+			r.Message, _ = val.(string)
 		default:
-			v, e := parseTaskVal(val)
-			if e == nil {
-				r.Tasks[key] = v //val.(map[string]interface{})
+			if field != nil && field(key, val) {
+				continue
+			}
+			if v, ok := val.(map[string]interface{}); ok {
+				r.Tasks[key] = v
 			} else {
 				r.Others[key] = val
 			}
 		}
 	}
-	return &r
+	return r, nil
+}
+
+// parseCode returns the code of a result, which is a json number or a numeric string
+func parseCode(val interface{}) (int, error) {
+	switch v := val.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return int(v), nil
+	case string:
+		code, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid result code: %q", v)
+		}
+		return code, nil
+	}
+	return 0, fmt.Errorf("invalid result code: %v", val)
+}
+
+// ToFloat converts a json number, a numeric string or a bool to float64, other values are 0
+func ToFloat(val interface{}) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case string:
+		var f float64
+		fmt.Sscan(v, &f)
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
 }
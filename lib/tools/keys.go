@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// FormatPKCS1 is the "RSA PRIVATE KEY" or "RSA PUBLIC KEY" PEM block
+	FormatPKCS1 = "pkcs1"
+	// FormatPKCS8 is the "PRIVATE KEY" PEM block
+	FormatPKCS8 = "pkcs8"
+	// FormatPKIX is the "PUBLIC KEY" PEM block, which is uploaded to TUPU
+	FormatPKIX = "pkix"
+
+	// DefaultKeyBits is the default size of the generated keys
+	DefaultKeyBits = 2048
+)
+
+// KeyInfo is the description of a RSA key in PEM
+type KeyInfo struct {
+	// Private is true for a private key, PrivateKey is set then
+	Private    bool
+	Format     string
+	Bits       int
+	PublicKey  *rsa.PublicKey
+	PrivateKey *rsa.PrivateKey
+}
+
+// GenerateKeyPair returns a new private key in format (pkcs1 or pkcs8) and its public key
+// in pkix, both in PEM
+func GenerateKeyPair(bits int, format string) (privatePEM, publicPEM []byte, err error) {
+	if bits <= 0 {
+		bits = DefaultKeyBits
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if privatePEM, err = EncodePrivateKey(key, format); err != nil {
+		return nil, nil, err
+	}
+	if publicPEM, err = EncodePublicKey(&key.PublicKey, FormatPKIX); err != nil {
+		return nil, nil, err
+	}
+	return privatePEM, publicPEM, nil
+}
+
+// EncodePrivateKey returns the private key in PEM of format, pkcs1 or pkcs8
+func EncodePrivateKey(key *rsa.PrivateKey, format string) ([]byte, error) {
+	switch format {
+	case FormatPKCS1, "":
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+	case FormatPKCS8:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key format %q", format)
+	}
+}
+
+// EncodePublicKey returns the public key in PEM of format, pkix or pkcs1
+func EncodePublicKey(key *rsa.PublicKey, format string) ([]byte, error) {
+	switch format {
+	case FormatPKIX, "":
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	case FormatPKCS1:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(key)}), nil
+	default:
+		return nil, fmt.Errorf("unsupported public key format %q", format)
+	}
+}
+
+// InspectKey parses the first RSA key in PEM, private or public
+func InspectKey(pemBytes []byte) (*KeyInfo, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("ssh: no key found")
+	}
+
+	info := new(KeyInfo)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		info.Private, info.Format, info.PrivateKey = true, FormatPKCS1, key
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("ssh: unsupported key type %T", key)
+		}
+		info.Private, info.Format, info.PrivateKey = true, FormatPKCS8, rsaKey
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("ssh: unsupported key type %T", key)
+		}
+		info.Format, info.PublicKey = FormatPKIX, rsaKey
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		info.Format, info.PublicKey = FormatPKCS1, key
+	default:
+		return nil, fmt.Errorf("ssh: unsupported key type %q", block.Type)
+	}
+
+	if info.Private {
+		if err := info.PrivateKey.Validate(); err != nil {
+			return nil, err
+		}
+		info.PublicKey = &info.PrivateKey.PublicKey
+	}
+	info.Bits = info.PublicKey.N.BitLen()
+	return info, nil
+}
+
+// Signer returns the signer of the private key, nil for a public key
+func (info *KeyInfo) Signer() Signer {
+	if info.PrivateKey == nil {
+		return nil
+	}
+	return &RsaPrivateKey{info.PrivateKey}
+}
+
+// Verifier returns the verifier of the public key
+func (info *KeyInfo) Verifier() Verifier {
+	return &RsaPublicKey{info.PublicKey}
+}
+
+// Fingerprint returns the SHA256 fingerprint of the public key in pkix, such as "SHA256:base64"
+func Fingerprint(key *rsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of the public key in pkix, such as "MD5:ab:cd:..."
+func FingerprintMD5(key *rsa.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	sum := md5.Sum(der)
+	hexes := make([]string, len(sum))
+	for i, b := range sum {
+		hexes[i] = fmt.Sprintf("%02x", b)
+	}
+	return "MD5:" + strings.Join(hexes, ":")
+}
+
+// SignatureMessage returns the message signed in the request, "secretId,timestamp,nonce"
+func SignatureMessage(secretID, timestamp, nonce string) string {
+	return strings.Join([]string{secretID, timestamp, nonce}, ",")
+}
+
+// SignString signs the message and returns the signature in base64
+func SignString(signer Signer, message string) (string, error) {
+	signed, err := signer.Sign([]byte(message))
+	if err != nil {
+		return "", fmt.Errorf("could not sign message: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+// VerifyString verifies the signature in base64 of the message
+func VerifyString(verifier Verifier, message, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("could not decode with Base64: %v", err)
+	}
+	if err = verifier.Verify([]byte(message), sig); err != nil {
+		return fmt.Errorf("could not verify signature: %v", err)
+	}
+	return nil
+}
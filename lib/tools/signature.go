@@ -53,6 +53,12 @@ func parsePrivateKey(pemBytes []byte) (Signer, error) {
 			return nil, err
 		}
 		rawkey = rsa
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rawkey = key
 	default:
 		return nil, fmt.Errorf("ssh: unsupported key type %q", block.Type)
 	}
@@ -28,14 +28,17 @@ func (r *RsaPublicKey) Verify(message []byte, sig []byte) error {
 	return rsa.VerifyPKCS1v15(r.PublicKey, crypto.SHA256, d, sig)
 }
 
-//LoadTupuPublicKey for load embeded TUPU's public key
-func LoadTupuPublicKey() (Verifier, error) {
-	return parsePublicKey([]byte(`-----BEGIN PUBLIC KEY-----
+// TupuPublicKeyPEM is TUPU's public key to verify the signature of responses
+const TupuPublicKeyPEM = `-----BEGIN PUBLIC KEY-----
 MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDyZneSY2eGnhKrArxaT6zswVH9
 /EKz+CLD+38kJigWj5UaRB6dDUK9BR6YIv0M9vVQZED2650tVhS3BeX04vEFhThn
 NrJguVPidufFpEh3AgdYDzOQxi06AN+CGzOXPaigTurBxZDIbdU+zmtr6a8bIBBj
 WQ4v2JR/BA6gVHV5TwIDAQAB
------END PUBLIC KEY-----`))
+-----END PUBLIC KEY-----`
+
+//LoadTupuPublicKey for load embeded TUPU's public key
+func LoadTupuPublicKey() (Verifier, error) {
+	return parsePublicKey([]byte(TupuPublicKeyPEM))
 }
 
 func parsePublicKey(pemBytes []byte) (Verifier, error) {
@@ -52,6 +55,12 @@ func parsePublicKey(pemBytes []byte) (Verifier, error) {
 			return nil, err
 		}
 		rawkey = rsa
+	case "RSA PUBLIC KEY":
+		rsa, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rawkey = rsa
 	default:
 		return nil, fmt.Errorf("ssh: unsupported key type %q", block.Type)
 	}
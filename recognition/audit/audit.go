@@ -0,0 +1,294 @@
+// Package audit provide a tamper-evident audit log of the moderation decisions, every request to
+// TUPU is appended as a json line which is chained to the previous line by SHA-256
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	"github.com/tuputech/tupu-go-sdk/recognition/policy"
+)
+
+// ErrBrokenChain is wrapped by the errors of a log which is modified, truncated or reordered
+var ErrBrokenChain = errors.New("audit chain is broken")
+
+// hashField is the last field of a line, the hash is over the line without it
+const hashField = `,"hash":"`
+
+type (
+	// Entry is a line of the audit log
+	Entry struct {
+		// Seq starts from 1
+		Seq      uint64    `json:"seq"`
+		Time     time.Time `json:"time"`
+		API      string    `json:"api"`
+		SecretID string    `json:"secretId"`
+		Tasks    []string  `json:"tasks,omitempty"`
+		// Contents is the hash of every content checked, see tupucontrol.Exchange
+		Contents   []string `json:"contents,omitempty"`
+		Cached     bool     `json:"cached,omitempty"`
+		DurationMs int64    `json:"durationMs"`
+		StatusCode int      `json:"statusCode"`
+		Error      string   `json:"error,omitempty"`
+		// Result is omitted by WithResult(false), ResultHash is always kept
+		Result     string `json:"result,omitempty"`
+		ResultHash string `json:"resultHash,omitempty"`
+		// Signature is the signature of TUPU on Result
+		Signature     string                   `json:"signature,omitempty"`
+		PolicyVersion string                   `json:"policyVersion,omitempty"`
+		Actions       map[string]policy.Action `json:"actions,omitempty"`
+		// Prev is the hash of the previous entry, empty for the first entry
+		Prev string `json:"prev"`
+		// Hash is the hex SHA-256 of the line without the hash field
+		Hash string `json:"hash,omitempty"`
+	}
+
+	// Log appends the entries to a file, it is safe for concurrent use
+	Log struct {
+		mu         sync.Mutex
+		file       *os.File
+		seq        uint64
+		last       string
+		syncWrite  bool
+		withResult bool
+		evaluate   func(result string) (string, map[string]*policy.Decision, error)
+		onError    func(error)
+	}
+
+	// LogOptFunc is the optional setting of Log
+	LogOptFunc func(*Log)
+)
+
+// WithPolicy records the actions of the policy on the results
+func WithPolicy(p *policy.Policy) LogOptFunc {
+	return func(l *Log) {
+		l.evaluate = func(result string) (string, map[string]*policy.Decision, error) {
+			decisions, err := p.Evaluate(result)
+			return p.Version, decisions, err
+		}
+	}
+}
+
+// WithPolicyLoader records the actions of the current policy of the loader on the results
+func WithPolicyLoader(loader *policy.Loader) LogOptFunc {
+	return func(l *Log) {
+		l.evaluate = func(result string) (string, map[string]*policy.Decision, error) {
+			snap := loader.Current()
+			decisions, err := snap.Policy.Evaluate(result)
+			return snap.Version, decisions, err
+		}
+	}
+}
+
+// WithResult sets whether the results are kept in the log, true by default. The hash of
+// the result is kept anyway
+func WithResult(withResult bool) LogOptFunc {
+	return func(l *Log) {
+		l.withResult = withResult
+	}
+}
+
+// WithSyncWrite calls fsync after every entry
+func WithSyncWrite(syncWrite bool) LogOptFunc {
+	return func(l *Log) {
+		l.syncWrite = syncWrite
+	}
+}
+
+// WithErrorFunc sets the function called when the Interceptor fails to write an entry
+func WithErrorFunc(onError func(error)) LogOptFunc {
+	return func(l *Log) {
+		l.onError = onError
+	}
+}
+
+// Open opens or creates the log file at path, the chain is continued from the last entry.
+// A broken last line is not repaired, it is reported with ErrBrokenChain
+func Open(path string, optFuncs ...LogOptFunc) (*Log, error) {
+	if tupuerror.StringIsEmpty(path) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	l := &Log{withResult: true}
+	for _, setConf := range optFuncs {
+		setConf(l)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	line, err := lastLine(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if len(line) > 0 {
+		entry, err := parseLine(line)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%w: %s: last line: %v", ErrBrokenChain, path, err)
+		}
+		l.seq, l.last = entry.Seq, entry.Hash
+	}
+	l.file = file
+	return l, nil
+}
+
+// Interceptor returns the function to set by SetInterceptor of the handlers
+func (l *Log) Interceptor() tupucontrol.Interceptor {
+	return func(ex *tupucontrol.Exchange) {
+		if err := l.Record(ex); err != nil && l.onError != nil {
+			l.onError(err)
+		}
+	}
+}
+
+// Record appends the exchange to the log
+func (l *Log) Record(ex *tupucontrol.Exchange) error {
+	entry := &Entry{
+		Time:       ex.Started,
+		API:        ex.APIURL,
+		SecretID:   ex.SecretID,
+		Tasks:      ex.Tasks,
+		Contents:   ex.Contents,
+		Cached:     ex.Cached,
+		DurationMs: ex.Duration.Milliseconds(),
+		StatusCode: ex.StatusCode,
+		Signature:  ex.Signature,
+	}
+	if ex.Err != nil {
+		entry.Error = ex.Err.Error()
+	}
+	if len(ex.Result) > 0 {
+		sum := sha256.Sum256([]byte(ex.Result))
+		entry.ResultHash = hex.EncodeToString(sum[:])
+		if l.withResult {
+			entry.Result = ex.Result
+		}
+		if l.evaluate != nil && ex.Err == nil && ex.StatusCode <= 299 {
+			version, decisions, err := l.evaluate(ex.Result)
+			if err == nil {
+				entry.PolicyVersion = version
+				entry.Actions = make(map[string]policy.Action, len(decisions))
+				for item, d := range decisions {
+					entry.Actions[item] = d.Action
+				}
+			}
+		}
+	}
+	return l.Append(entry)
+}
+
+// Append sets the Seq, Prev and Hash of the entry and appends it to the log
+func (l *Log) Append(entry *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq, entry.Prev, entry.Hash = l.seq+1, l.last, ""
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	hash := hashOf(body)
+	line := make([]byte, 0, len(body)+len(hashField)+len(hash)+3)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, hashField...)
+	line = append(line, hash...)
+	line = append(line, '"', '}', '\n')
+
+	if _, err = l.file.Write(line); err != nil {
+		return err
+	}
+	if l.syncWrite {
+		if err = l.file.Sync(); err != nil {
+			return err
+		}
+	}
+	l.seq, l.last, entry.Hash = entry.Seq, hash, hash
+	return nil
+}
+
+// Head returns the seq and the hash of the last entry, it can be published elsewhere so the
+// truncation of the log is detectable
+func (l *Log) Head() (uint64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq, l.last
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// parseLine checks the hash of the line and returns its entry, the error tells what is broken
+func parseLine(line []byte) (*Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	idx := bytes.LastIndex(line, []byte(hashField))
+	if idx < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, errors.New("no hash")
+	}
+	var (
+		hash = string(line[idx+len(hashField) : len(line)-2])
+		body = append(append([]byte(nil), line[:idx]...), '}')
+	)
+	if hashOf(body) != hash {
+		return nil, errors.New("hash mismatch")
+	}
+	entry := new(Entry)
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// lastLine returns the last non-empty line of the file
+func lastLine(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		size  = info.Size()
+		chunk = int64(64 * 1024)
+		tail  []byte
+	)
+	for offset := size; offset > 0; {
+		n := chunk
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		buf := make([]byte, n)
+		if _, err = file.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if idx := bytes.LastIndexByte(trimmed, '\n'); idx >= 0 {
+			return trimmed[idx+1:], nil
+		}
+		if offset == 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
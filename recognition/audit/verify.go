@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+
+	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
+)
+
+type (
+	// Report is the summary of a verified log
+	Report struct {
+		Entries  int
+		LastSeq  uint64
+		LastHash string
+		// Signatures is the number of TUPU signatures verified
+		Signatures int
+	}
+
+	// ChainError is the first broken line of a log
+	ChainError struct {
+		Line   int
+		Seq    uint64
+		Reason string
+	}
+
+	// VerifyOptFunc is the optional setting of Verify
+	VerifyOptFunc func(*verifyConfig)
+
+	verifyConfig struct {
+		verifier tuputools.Verifier
+	}
+)
+
+// WithSignatureCheck verifies the TUPU signatures of the results kept in the log, the verifier
+// is usually tuputools.LoadTupuPublicKey
+func WithSignatureCheck(verifier tuputools.Verifier) VerifyOptFunc {
+	return func(c *verifyConfig) {
+		c.verifier = verifier
+	}
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("%v at line %d (seq %d): %s", ErrBrokenChain, e.Line, e.Seq, e.Reason)
+}
+
+// Unwrap returns ErrBrokenChain
+func (e *ChainError) Unwrap() error {
+	return ErrBrokenChain
+}
+
+// VerifyFile verifies the log file at path, the report is never nil
+func VerifyFile(path string, optFuncs ...VerifyOptFunc) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return new(Report), err
+	}
+	defer file.Close()
+	return Verify(file, optFuncs...)
+}
+
+// Verify checks the hash of every line and the chain of the lines, the report of the lines
+// before the first broken one is returned with a *ChainError
+func Verify(r io.Reader, optFuncs ...VerifyOptFunc) (*Report, error) {
+	conf := new(verifyConfig)
+	for _, setConf := range optFuncs {
+		setConf(conf)
+	}
+
+	var (
+		report = new(Report)
+		reader = bufio.NewReader(r)
+		lineNo = 0
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return report, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			lineNo++
+			if cerr := report.check(conf, line, lineNo); cerr != nil {
+				return report, cerr
+			}
+		}
+		if err == io.EOF {
+			return report, nil
+		}
+	}
+}
+
+func (report *Report) check(conf *verifyConfig, line []byte, lineNo int) *ChainError {
+	broken := func(seq uint64, format string, args ...interface{}) *ChainError {
+		return &ChainError{Line: lineNo, Seq: seq, Reason: fmt.Sprintf(format, args...)}
+	}
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		return broken(report.LastSeq+1, "truncated line")
+	}
+
+	entry, err := parseLine(line)
+	switch {
+	case err != nil:
+		return broken(report.LastSeq+1, "%v", err)
+	case entry.Seq != report.LastSeq+1:
+		return broken(entry.Seq, "seq %d follows %d", entry.Seq, report.LastSeq)
+	case entry.Prev != report.LastHash:
+		return broken(entry.Seq, "prev %q isn't the hash of the previous entry %q", entry.Prev, report.LastHash)
+	case len(entry.Result) > 0 && len(entry.ResultHash) > 0 && hashOf([]byte(entry.Result)) != entry.ResultHash:
+		return broken(entry.Seq, "result doesn't match resultHash")
+	}
+
+	if conf.verifier != nil && len(entry.Signature) > 0 && len(entry.Result) > 0 {
+		sig, err := base64.StdEncoding.DecodeString(entry.Signature)
+		if err == nil {
+			err = conf.verifier.Verify([]byte(entry.Result), sig)
+		}
+		if err != nil {
+			return broken(entry.Seq, "TUPU signature: %v", err)
+		}
+		report.Signatures++
+	}
+
+	report.Entries++
+	report.LastSeq, report.LastHash = entry.Seq, entry.Hash
+	return nil
+}
@@ -0,0 +1,161 @@
+package recognition
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	tupuimagehash "github.com/tuputech/tupu-go-sdk/lib/imagehash"
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+const (
+	// InferredKey is set to true in the fileList element of an image whose result is inferred
+	// from a near-duplicate image instead of being recognized
+	InferredKey = "inferred"
+	// InferredFromKey is the name of the near-duplicate image the result is inferred from
+	InferredFromKey = "inferredFrom"
+	// DistanceKey is the Hamming distance between the image and the near-duplicate image
+	DistanceKey = "distance"
+)
+
+// SetDedup provide collapsing near-duplicate images by perceptual hash. The images within
+// maxDistance of an image in the same batch or of an image recognized before with the same
+// secretID and tasks are not sent, their results are inferred and marked with InferredKey.
+// Only local and binary images are hashed. nil index disables it
+func (h *Handler) SetDedup(index *tupuimagehash.Index, maxDistance int) {
+	h.dedupIndex = index
+	h.maxDistance = maxDistance
+}
+
+// performWithDedup sends the images without near-duplicates, and infers the results of the rest
+func (h *Handler) performWithDedup(secretID string, dataInfoSlice []*tupumodel.DataInfo, tasks []string) (result string, statusCode int, e error) {
+	var (
+		scope     = secretID + "|" + strings.Join(tasks, ",")
+		size      = len(dataInfoSlice)
+		hashes    = make([]tupuimagehash.Hash, size)
+		hashed    = make([]bool, size)
+		items     = make([]tupumodel.ItemResult, size)
+		sources   = make([]int, size)
+		sendIdxs  []int
+		sendInfos []*tupumodel.DataInfo
+	)
+
+	// step1. hash every image, and find its near-duplicate recognized before or in the batch
+	for i, dataInfo := range dataInfoSlice {
+		sources[i] = -1
+		if buf, err := imageBytes(dataInfo); err == nil {
+			if hashes[i], err = tupuimagehash.FromBytes(h.dedupIndex.Kind(), buf); err == nil {
+				hashed[i] = true
+			}
+		}
+
+		if hashed[i] {
+			if val, dist, ok := h.dedupIndex.Nearest(scope, hashes[i], h.maxDistance); ok {
+				var item tupumodel.ItemResult
+				if json.Unmarshal([]byte(val), &item) == nil {
+					items[i] = markInferred(item, imageName(dataInfo), dist)
+					continue
+				}
+			}
+			for _, j := range sendIdxs {
+				if hashed[j] && tupuimagehash.Distance(hashes[i], hashes[j]) <= h.maxDistance {
+					sources[i] = j
+					break
+				}
+			}
+			if sources[i] >= 0 {
+				continue
+			}
+		}
+		sendIdxs = append(sendIdxs, i)
+		sendInfos = append(sendInfos, dataInfo)
+	}
+
+	// step2. recognize the distinct images, and index their results
+	if len(sendInfos) > 0 {
+		if result, statusCode, e = h.hdler.Recognize(secretID, sendInfos, tasks); e != nil || statusCode > 299 {
+			return
+		}
+		fresh, err := tupumodel.SplitResult(result, len(sendInfos))
+		if err != nil {
+			if len(sendInfos) == size {
+				return
+			}
+			e = fmt.Errorf("could not merge inferred results: %v", err)
+			return
+		}
+		for k, i := range sendIdxs {
+			items[i] = fresh[k]
+			if !hashed[i] {
+				continue
+			}
+			if val, err := json.Marshal(fresh[k]); err == nil {
+				h.dedupIndex.Add(scope, hashes[i], string(val))
+			}
+		}
+		if len(sendInfos) == size {
+			return
+		}
+	} else {
+		statusCode = 200
+	}
+
+	// step3. infer the results of the near-duplicates in the batch, and merge all results
+	for i, j := range sources {
+		if j >= 0 {
+			items[i] = markInferred(items[j], imageName(dataInfoSlice[i]), tupuimagehash.Distance(hashes[i], hashes[j]))
+		}
+	}
+	result, e = tupumodel.MergeResult(result, items)
+	return
+}
+
+// markInferred returns a copy of item marked as inferred, the name is replaced with the inferred image
+func markInferred(item tupumodel.ItemResult, name string, distance int) tupumodel.ItemResult {
+	inferred := make(tupumodel.ItemResult, len(item))
+	for taskID, raw := range item {
+		var file map[string]interface{}
+		if json.Unmarshal(raw, &file) != nil {
+			inferred[taskID] = raw
+			continue
+		}
+		if from, ok := file["name"]; ok {
+			file[InferredFromKey] = from
+		}
+		if len(name) > 0 {
+			file["name"] = name
+		}
+		file[InferredKey] = true
+		file[DistanceKey] = distance
+		inferred[taskID], _ = json.Marshal(file)
+	}
+	return inferred
+}
+
+func imageBytes(dataInfo *tupumodel.DataInfo) ([]byte, error) {
+	switch {
+	case len(dataInfo.RemoteInfo) > 0:
+		return nil, errors.New("remote image can't be hashed")
+	case len(dataInfo.Path) > 0:
+		return ioutil.ReadFile(dataInfo.Path)
+	case dataInfo.Buf != nil && dataInfo.Buf.Len() > 0:
+		return dataInfo.Buf.Bytes(), nil
+	default:
+		return nil, errors.New("invalid image resource")
+	}
+}
+
+func imageName(dataInfo *tupumodel.DataInfo) string {
+	switch {
+	case len(dataInfo.RemoteInfo) > 0:
+		return dataInfo.RemoteInfo
+	case len(dataInfo.Path) > 0:
+		return filepath.Base(dataInfo.Path)
+	default:
+		return dataInfo.FileName
+	}
+}
@@ -0,0 +1,165 @@
+package recognition
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	tupuimageproc "github.com/tuputech/tupu-go-sdk/lib/imageproc"
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+const (
+	// MaxBatchSize is the max number of images carried in one request
+	MaxBatchSize = 10
+
+	// FramesKey is the number of sampled frames in the fileList element of an animated GIF
+	FramesKey = "frames"
+	// FrameIndexKey is the index in the GIF of the frame whose result is taken as the GIF's
+	FrameIndexKey = "frameIndex"
+	// OffendingFramesKey is the indexes in the GIF of the frames not labeled normal or to be reviewed
+	OffendingFramesKey = "offendingFrames"
+)
+
+// DefaultNormalLabels is the label of normal images of the tasks whose normal label is not 0
+var DefaultNormalLabels = map[string]int{
+	// porn recognition: 0 porn, 1 sexy, 2 normal
+	"54bcfc6c329af61034f7c2fc": 2,
+}
+
+// SetFrameSampler provide recognizing the sampled frames of animated GIFs instead of the GIFs.
+// The frame results are aggregated into one fileList element per GIF, the offending frames are
+// the frames whose label differs from normalLabels of the task (0 if absent) or to be reviewed.
+// DefaultNormalLabels is used if normalLabels is nil. nil sampler disables it
+func (h *Handler) SetFrameSampler(sampler *tupuimageproc.FrameSampler, normalLabels map[string]int) {
+	if normalLabels == nil {
+		normalLabels = DefaultNormalLabels
+	}
+	h.frameSampler = sampler
+	h.normalLabels = normalLabels
+}
+
+// performWithFrames replaces the animated GIFs with their sampled frames, and aggregates the frame results
+func (h *Handler) performWithFrames(secretID string, dataInfoSlice []*tupumodel.DataInfo, tasks []string) (result string, statusCode int, e error) {
+	var (
+		expanded    []*tupumodel.DataInfo
+		groups      = make([][]int, len(dataInfoSlice))
+		frameIdxs   = make([][]int, len(dataInfoSlice))
+		hasAnimated = false
+	)
+
+	// step1. sample the frames of the animated GIFs
+	for i, dataInfo := range dataInfoSlice {
+		var frames []tupuimageproc.Frame
+		if buf, err := imageBytes(dataInfo); err == nil {
+			// a broken GIF is sent as is, and judged by TUPU
+			frames, _ = h.frameSampler.Sample(buf)
+		}
+		if len(frames) == 0 {
+			groups[i] = []int{len(expanded)}
+			expanded = append(expanded, dataInfo)
+			continue
+		}
+
+		hasAnimated = true
+		name := imageName(dataInfo)
+		for _, frame := range frames {
+			groups[i] = append(groups[i], len(expanded))
+			frameIdxs[i] = append(frameIdxs[i], frame.Index)
+			expanded = append(expanded, &tupumodel.DataInfo{
+				Buf:      bytes.NewBuffer(frame.Data),
+				FileType: dataInfo.FileType,
+				FileName: fmt.Sprintf("%s#%d.jpg", name, frame.Index),
+				OtherMsg: dataInfo.OtherMsg,
+			})
+		}
+	}
+	if !hasAnimated {
+		return h.recognize(secretID, dataInfoSlice, tasks)
+	}
+
+	// step2. recognize the frames and other images in batches
+	var items []tupumodel.ItemResult
+	for start := 0; start < len(expanded); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(expanded) {
+			end = len(expanded)
+		}
+		batchResult, batchCode, err := h.recognize(secretID, expanded[start:end], tasks)
+		if err != nil || batchCode > 299 {
+			return batchResult, batchCode, err
+		}
+		batchItems, err := tupumodel.SplitResult(batchResult, end-start)
+		if err != nil {
+			// a failed result is returned as is
+			return batchResult, batchCode, nil
+		}
+		if start == 0 {
+			result, statusCode = batchResult, batchCode
+		}
+		items = append(items, batchItems...)
+	}
+
+	// step3. aggregate the frame results into one result per GIF
+	merged := make([]tupumodel.ItemResult, len(dataInfoSlice))
+	for i, group := range groups {
+		if frameIdxs[i] == nil {
+			merged[i] = items[group[0]]
+			continue
+		}
+		frames := make([]tupumodel.ItemResult, len(group))
+		for k, idx := range group {
+			frames[k] = items[idx]
+		}
+		merged[i] = h.aggregateFrames(frames, frameIdxs[i], imageName(dataInfoSlice[i]))
+	}
+	result, e = tupumodel.MergeResult(result, merged)
+	return
+}
+
+// aggregateFrames takes the most confident offending frame as the result of every task, or the
+// most confident frame if there is no offending one
+func (h *Handler) aggregateFrames(frames []tupumodel.ItemResult, frameIdxs []int, name string) tupumodel.ItemResult {
+	aggregated := make(tupumodel.ItemResult)
+	for taskID := range frames[0] {
+		var (
+			normal     = h.normalLabels[taskID]
+			chosen     map[string]interface{}
+			chosenIdx  = -1
+			chosenBad  = false
+			chosenRate float64
+			offending  = []int{}
+			review     = false
+		)
+		for k, frame := range frames {
+			var file map[string]interface{}
+			if json.Unmarshal(frame[taskID], &file) != nil {
+				continue
+			}
+			label, _ := file["label"].(float64)
+			rate, _ := file["rate"].(float64)
+			r, _ := file["review"].(bool)
+			bad := int(label) != normal || r
+			review = review || r
+			if bad {
+				offending = append(offending, frameIdxs[k])
+			}
+			if chosen == nil || (bad && !chosenBad) || (bad == chosenBad && rate > chosenRate) {
+				chosen, chosenIdx, chosenBad, chosenRate = file, frameIdxs[k], bad, rate
+			}
+		}
+		if chosen == nil {
+			continue
+		}
+
+		chosen["name"] = name
+		chosen[FramesKey] = len(frames)
+		chosen[FrameIndexKey] = chosenIdx
+		chosen[OffendingFramesKey] = offending
+		if _, ok := chosen["review"]; ok {
+			chosen["review"] = review
+		}
+		aggregated[taskID], _ = json.Marshal(chosen)
+	}
+	return aggregated
+}
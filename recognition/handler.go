@@ -1,13 +1,21 @@
 package recognition
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"sync"
+	"time"
 
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
 	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupuimagehash "github.com/tuputech/tupu-go-sdk/lib/imagehash"
+	tupuimageproc "github.com/tuputech/tupu-go-sdk/lib/imageproc"
+	tupumediatype "github.com/tuputech/tupu-go-sdk/lib/mediatype"
 	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
 )
 
@@ -32,6 +40,13 @@ type (
 		//
 		UID       string //for sub-user statistics and billing
 		UserAgent string
+
+		dedupIndex   *tupuimagehash.Index
+		maxDistance  int
+		preprocessor *tupuimageproc.Processor
+		frameSampler *tupuimageproc.FrameSampler
+		normalLabels map[string]int
+		mediaRule    *tupumediatype.Rule
 	}
 )
 
@@ -45,6 +60,7 @@ func NewHandler(privateKeyPath string) (*Handler, error) {
 	if e != nil {
 		return nil, e
 	}
+	h.mediaRule = tupumediatype.ImageRule
 	h.imgPool.New = func() interface{} {
 		return newImage()
 	}
@@ -58,9 +74,34 @@ func NewHandlerWithURL(privateKeyPath, url string) (h *Handler, e error) {
 	if e != nil {
 		return nil, e
 	}
+	h.mediaRule = tupumediatype.ImageRule
+	h.imgPool.New = func() interface{} {
+		return newImage()
+	}
 	return h, nil
 }
 
+// SetMediaRule provide setting the formats and size limit checked before uploading local and
+// binary images, after the preprocessing. tupumediatype.ImageRule by default, nil disables the check
+func (h *Handler) SetMediaRule(rule *tupumediatype.Rule) {
+	h.mediaRule = rule
+}
+
+// SetCache provide setting the cache of recognition results keyed by content hash, nil disables it
+func (h *Handler) SetCache(cache tupucache.Cache, ttl time.Duration) {
+	h.hdler.SetCache(cache, ttl)
+}
+
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (h *Handler) SetTransport(transport http.RoundTripper) {
+	h.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (h *Handler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	h.hdler.SetInterceptor(interceptor)
+}
+
 func (h *Handler) WithTags(tags []string) options {
 	return func(c *config) {
 		c.tags = tags
@@ -126,8 +167,82 @@ func (h *Handler) Perform(secretID string, images []*Image, tags []string, tasks
 		dataInfoSlice[i] = images[i].dataInfo
 	}
 
+	if h.frameSampler != nil {
+		return h.performWithFrames(secretID, dataInfoSlice, tasks)
+	}
+	return h.recognize(secretID, dataInfoSlice, tasks)
+
+}
+
+// recognize preprocesses the images and sends them
+func (h *Handler) recognize(secretID string, dataInfoSlice []*tupumodel.DataInfo, tasks []string) (result string, statusCode int, e error) {
+	if h.preprocessor != nil {
+		for i := range dataInfoSlice {
+			name := imageName(dataInfoSlice[i])
+			if dataInfoSlice[i], e = h.preprocess(dataInfoSlice[i]); e != nil {
+				statusCode = 400
+				e = fmt.Errorf("invalid image %s at index [%v]: %w", name, i, e)
+				return
+			}
+		}
+	}
+
+	if h.mediaRule != nil {
+		for i, dataInfo := range dataInfoSlice {
+			if _, e = h.mediaRule.ValidateDataInfo(dataInfo); e != nil {
+				statusCode = 400
+				e = fmt.Errorf("invalid image at index [%v]: %w", i, e)
+				return
+			}
+		}
+	}
+
+	if h.dedupIndex != nil {
+		return h.performWithDedup(secretID, dataInfoSlice, tasks)
+	}
 	return h.hdler.Recognize(secretID, dataInfoSlice, tasks)
+}
+
+// SetPreprocessor provide processing the local and binary images before uploading, such as
+// downscaling and stripping EXIF. The invalid images are rejected without sending. nil disables it
+func (h *Handler) SetPreprocessor(p *tupuimageproc.Processor) {
+	h.preprocessor = p
+}
+
+// preprocess returns the DataInfo with the processed image, dataInfo of the caller is not changed
+func (h *Handler) preprocess(dataInfo *tupumodel.DataInfo) (*tupumodel.DataInfo, error) {
+	var (
+		buf  []byte
+		name string
+		err  error
+	)
+	switch {
+	case len(dataInfo.RemoteInfo) > 0:
+		return dataInfo, nil
+	case len(dataInfo.Path) > 0:
+		if buf, err = ioutil.ReadFile(dataInfo.Path); err != nil {
+			return nil, err
+		}
+		name = filepath.Base(dataInfo.Path)
+	case dataInfo.Buf != nil && dataInfo.Buf.Len() > 0:
+		buf, name = dataInfo.Buf.Bytes(), dataInfo.FileName
+	default:
+		return dataInfo, nil
+	}
+
+	out, outName, err := h.preprocessor.Process(buf, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == len(buf) && &out[0] == &buf[0] && dataInfo.Buf != nil {
+		return dataInfo, nil
+	}
 
+	processed := *dataInfo
+	processed.Path = ""
+	processed.Buf = bytes.NewBuffer(out)
+	processed.FileName = outName
+	return &processed, nil
 }
 
 func (h *Handler) recycleDataObj(img *Image) {
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	tupumediatype "github.com/tuputech/tupu-go-sdk/lib/mediatype"
+)
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// ErrUnsupportedMedia is returned for the uploads of which the kind isn't detected, such as svg
+// images, they are rejected with 415 unless WithFailOpen is set
+var ErrUnsupportedMedia = errors.New("unsupported media of upload")
+
+// extract returns the contents of a multipart or json request, the body is restored so the
+// handler can read it again
+func (m *Middleware) extract(r *http.Request) ([]*Content, error) {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return nil, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil
+	}
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if mediaType != "multipart/form-data" && !isJSON {
+		return nil, nil
+	}
+	if isJSON && len(m.textFields) == 0 {
+		return nil, nil
+	}
+
+	// step1. read and restore the body
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, m.maxBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > m.maxBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// step2. extract the contents
+	names := make(map[string]int)
+	if isJSON {
+		return m.extractJSON(body, names)
+	}
+	return m.extractMultipart(body, params["boundary"], names)
+}
+
+func (m *Middleware) extractMultipart(body []byte, boundary string, names map[string]int) ([]*Content, error) {
+	if boundary == "" {
+		return nil, errors.New("no multipart boundary")
+	}
+
+	var (
+		reader   = multipart.NewReader(bytes.NewReader(body), boundary)
+		contents []*Content
+		// the uploads of which the kind isn't detected
+		unknown []string
+	)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			if len(unknown) > 0 {
+				return contents, fmt.Errorf("%w: %s", ErrUnsupportedMedia, strings.Join(unknown, ", "))
+			}
+			return contents, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %v", err)
+		}
+
+		field := part.FormName()
+		data, err := ioutil.ReadAll(part)
+		part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid multipart body: %v", err)
+		}
+
+		if fileName := part.FileName(); fileName != "" {
+			if len(data) == 0 {
+				continue
+			}
+			kind := m.fileKind(field, data)
+			if kind == "" {
+				unknown = append(unknown, field+"-"+path.Base(fileName))
+				continue
+			}
+			contents = append(contents, &Content{
+				Kind:        kind,
+				Field:       field,
+				Name:        uniqueName(names, field+"-"+path.Base(fileName)),
+				ContentType: part.Header.Get("Content-Type"),
+				Data:        data,
+			})
+		} else if m.isTextField(field) && len(bytes.TrimSpace(data)) > 0 {
+			contents = append(contents, &Content{
+				Kind:  KindText,
+				Field: field,
+				Name:  uniqueName(names, field),
+				Data:  data,
+			})
+		}
+	}
+}
+
+func (m *Middleware) extractJSON(body []byte, names map[string]int) ([]*Content, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid json body: %v", err)
+	}
+
+	var contents []*Content
+	addText := func(field, text string) {
+		if strings.TrimSpace(text) != "" {
+			contents = append(contents, &Content{
+				Kind:  KindText,
+				Field: field,
+				Name:  uniqueName(names, field),
+				Data:  []byte(text),
+			})
+		}
+	}
+	for _, field := range m.textFields {
+		switch v := lookup(doc, strings.Split(field, ".")).(type) {
+		case string:
+			addText(field, v)
+		case []interface{}:
+			for i, item := range v {
+				if text, ok := item.(string); ok {
+					addText(field+"."+strconv.Itoa(i), text)
+				}
+			}
+		}
+	}
+	return contents, nil
+}
+
+// fileKind returns the kind of a file by WithFieldKind or its header, empty if it isn't a media file
+func (m *Middleware) fileKind(field string, data []byte) string {
+	if kind, ok := m.fieldKinds[field]; ok {
+		return kind
+	}
+	switch tupumediatype.Detect(data).Kind {
+	case tupumediatype.KindImage:
+		return KindImage
+	case tupumediatype.KindAudio:
+		return KindSpeech
+	case tupumediatype.KindVideo:
+		return KindVideo
+	default:
+		return ""
+	}
+}
+
+func (m *Middleware) isTextField(field string) bool {
+	for _, name := range m.textFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the value of the keys in the json document, nil if it doesn't exist
+func lookup(doc interface{}, keys []string) interface{} {
+	for _, key := range keys {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		doc = obj[key]
+	}
+	return doc
+}
+
+// uniqueName returns name, or name with a suffix of "#<n>" if it is used in the request
+func uniqueName(names map[string]int, name string) string {
+	n := names[name]
+	names[name]++
+	if n == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s#%d", name, n)
+}
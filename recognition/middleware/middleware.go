@@ -0,0 +1,515 @@
+// Package middleware provide a net/http middleware which moderates the uploads of a request by
+// TUPU recognition and a policy before the request reaches the handler
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	"github.com/tuputech/tupu-go-sdk/recognition/policy"
+)
+
+const (
+	// KindImage is the kind of image uploads
+	KindImage = "image"
+	// KindText is the kind of text fields
+	KindText = "text"
+	// KindSpeech is the kind of audio uploads
+	KindSpeech = "speech"
+	// KindVideo is the kind of video uploads
+	KindVideo = "video"
+
+	// StateHold means the contents are being recognized in async mode
+	StateHold = "hold"
+	// StateDone means the verdict is decided
+	StateDone = "done"
+	// StateFailed means the contents failed to be recognized
+	StateFailed = "failed"
+
+	// HeaderAction is the request header of the action given to the handler by BehaviorTag
+	HeaderAction = "X-Moderation-Action"
+	// HeaderItems is the request header of the actions of the contents, such as `file-a.jpg=blur`
+	HeaderItems = "X-Moderation-Items"
+	// HeaderHold is the request header of the verdict id in async mode
+	HeaderHold = "X-Moderation-Hold"
+	// HeaderError is the request header of the recognition error with WithFailOpen
+	HeaderError = "X-Moderation-Error"
+
+	// DefaultMaxBodyBytes is the default limit of the request body
+	DefaultMaxBodyBytes = 32 << 20
+	// DefaultTimeout is the default timeout of recognizing a request
+	DefaultTimeout = 30 * time.Second
+	// DefaultHoldTTL is the default time a decided verdict of async mode is kept
+	DefaultHoldTTL = time.Hour
+)
+
+const (
+	// BehaviorPass passes the request to the handler as it is
+	BehaviorPass Behavior = iota
+	// BehaviorTag passes the request with the moderation headers
+	BehaviorTag
+	// BehaviorReject responds by the reject function, the handler isn't called
+	BehaviorReject
+)
+
+// ErrNoPolicy is returned by New without WithPolicy or WithPolicyLoader
+var ErrNoPolicy = errors.New("moderation middleware needs a policy")
+
+type (
+	// Behavior is what the middleware does with the request of an action
+	Behavior int
+
+	// Content is an upload or a text field of the request
+	Content struct {
+		Kind string `json:"kind"`
+		// Field is the form field or the json path of the content
+		Field string `json:"field"`
+		// Name is unique in the request, it is sent as the file name or the contentId, so the
+		// decisions of the policy are keyed by it
+		Name        string `json:"name"`
+		ContentType string `json:"contentType,omitempty"`
+		Data        []byte `json:"-"`
+	}
+
+	// RecognizeFunc recognizes the contents of a kind and returns the result json
+	RecognizeFunc func(ctx context.Context, contents []*Content) (result string, statusCode int, err error)
+
+	// Verdict is the moderation of a request
+	Verdict struct {
+		ID     string        `json:"id"`
+		State  string        `json:"state"`
+		Action policy.Action `json:"action,omitempty"`
+		// PolicyVersion is the version of the policy of the loader
+		PolicyVersion string             `json:"policyVersion,omitempty"`
+		Contents      []*Content         `json:"contents"`
+		Decisions     []*policy.Decision `json:"decisions,omitempty"`
+		// Results is the result json of every kind
+		Results   map[string]string `json:"-"`
+		Err       error             `json:"-"`
+		DecidedAt time.Time         `json:"decidedAt,omitempty"`
+	}
+
+	// Middleware moderates the requests, it is safe for concurrent use
+	Middleware struct {
+		recognizers  map[string]RecognizeFunc
+		policy       func() (*policy.Policy, string)
+		behaviors    map[policy.Action]Behavior
+		textFields   []string
+		fieldKinds   map[string]string
+		maxBodyBytes int64
+		timeout      time.Duration
+		failOpen     bool
+		async        bool
+		onDone       func(*Verdict)
+		holdTTL      time.Duration
+		rejectFunc   func(w http.ResponseWriter, r *http.Request, v *Verdict)
+		errorFunc    func(w http.ResponseWriter, r *http.Request, err error)
+
+		mu    sync.Mutex
+		holds map[string]*Verdict
+	}
+
+	// OptFunc is the optional setting of Middleware
+	OptFunc func(*Middleware)
+
+	contextKey struct{}
+)
+
+// WithRecognizer sets the function recognizing the contents of kind. The contents of a kind
+// without recognizer are passed to the handler unmoderated, the uploads of which the kind isn't
+// detected are rejected with ErrUnsupportedMedia
+func WithRecognizer(kind string, recognize RecognizeFunc) OptFunc {
+	return func(m *Middleware) {
+		m.recognizers[kind] = recognize
+	}
+}
+
+// WithPolicy decides the actions by the policy
+func WithPolicy(p *policy.Policy) OptFunc {
+	return func(m *Middleware) {
+		m.policy = func() (*policy.Policy, string) {
+			return p, p.Version
+		}
+	}
+}
+
+// WithPolicyLoader decides the actions by the current policy of the loader
+func WithPolicyLoader(loader *policy.Loader) OptFunc {
+	return func(m *Middleware) {
+		m.policy = func() (*policy.Policy, string) {
+			snap := loader.Current()
+			return snap.Policy, snap.Version
+		}
+	}
+}
+
+// WithBehavior sets what to do with the request of the action. By default block is rejected,
+// review and blur are tagged, and pass is passed
+func WithBehavior(action policy.Action, behavior Behavior) OptFunc {
+	return func(m *Middleware) {
+		m.behaviors[action] = behavior
+	}
+}
+
+// WithTextFields sets the form fields and the json paths of the texts to moderate, a json path
+// is the keys joined by dots, such as "post.title". An array of strings is moderated by item
+func WithTextFields(fields ...string) OptFunc {
+	return func(m *Middleware) {
+		m.textFields = append(m.textFields, fields...)
+	}
+}
+
+// WithFieldKind sets the kind of the files of a form field instead of detecting it by the file header
+func WithFieldKind(field, kind string) OptFunc {
+	return func(m *Middleware) {
+		m.fieldKinds[field] = kind
+	}
+}
+
+// WithMaxBodyBytes sets the limit of the request body, a larger body is rejected by 413
+func WithMaxBodyBytes(n int64) OptFunc {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.maxBodyBytes = n
+		}
+	}
+}
+
+// WithTimeout sets the timeout of recognizing a request
+func WithTimeout(timeout time.Duration) OptFunc {
+	return func(m *Middleware) {
+		if timeout > 0 {
+			m.timeout = timeout
+		}
+	}
+}
+
+// WithFailOpen passes the request with HeaderError when the recognition fails or an upload is
+// of ErrUnsupportedMedia, instead of responding by the error function or 415
+func WithFailOpen(failOpen bool) OptFunc {
+	return func(m *Middleware) {
+		m.failOpen = failOpen
+	}
+}
+
+// WithAsync passes the request at once with HeaderHold and a verdict in StateHold, the contents
+// are recognized in background and onDone is called with the decided verdict. The handler
+// keeps the uploads hidden until onDone, or polls them by Middleware.Verdict
+func WithAsync(onDone func(*Verdict)) OptFunc {
+	return func(m *Middleware) {
+		m.async = true
+		m.onDone = onDone
+	}
+}
+
+// WithHoldTTL sets the time a decided verdict of async mode is kept for Middleware.Verdict
+func WithHoldTTL(ttl time.Duration) OptFunc {
+	return func(m *Middleware) {
+		if ttl > 0 {
+			m.holdTTL = ttl
+		}
+	}
+}
+
+// WithRejectFunc sets the response of a rejected request, 403 with the verdict in json by default
+func WithRejectFunc(reject func(w http.ResponseWriter, r *http.Request, v *Verdict)) OptFunc {
+	return func(m *Middleware) {
+		m.rejectFunc = reject
+	}
+}
+
+// WithErrorFunc sets the response of a request failed to be moderated, 503 by default
+func WithErrorFunc(onError func(w http.ResponseWriter, r *http.Request, err error)) OptFunc {
+	return func(m *Middleware) {
+		m.errorFunc = onError
+	}
+}
+
+// New is an initializer for a Middleware, a policy is required
+func New(optFuncs ...OptFunc) (*Middleware, error) {
+	m := &Middleware{
+		recognizers: make(map[string]RecognizeFunc),
+		behaviors: map[policy.Action]Behavior{
+			policy.ActionPass:   BehaviorPass,
+			policy.ActionReview: BehaviorTag,
+			policy.ActionBlur:   BehaviorTag,
+			policy.ActionBlock:  BehaviorReject,
+		},
+		fieldKinds:   make(map[string]string),
+		maxBodyBytes: DefaultMaxBodyBytes,
+		timeout:      DefaultTimeout,
+		holdTTL:      DefaultHoldTTL,
+		rejectFunc:   reject,
+		errorFunc:    fail,
+		holds:        make(map[string]*Verdict),
+	}
+	for _, setConf := range optFuncs {
+		setConf(m)
+	}
+	if m.policy == nil {
+		return nil, ErrNoPolicy
+	}
+	if len(m.recognizers) == 0 {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	return m, nil
+}
+
+// FromContext returns the verdict of the request, nil if the request isn't moderated
+func FromContext(ctx context.Context) *Verdict {
+	v, _ := ctx.Value(contextKey{}).(*Verdict)
+	return v
+}
+
+// Wrap returns the handler which moderates the requests before next
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.serve(w, r, next)
+	})
+}
+
+// Verdict returns the verdict of async mode by the id in HeaderHold
+func (m *Middleware) Verdict(id string) (*Verdict, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.holds[id]
+	return v, ok
+}
+
+func (m *Middleware) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	// the moderation headers are only set by the middleware
+	for _, header := range []string{HeaderAction, HeaderItems, HeaderHold, HeaderError} {
+		r.Header.Del(header)
+	}
+
+	// step1. extract the contents
+	contents, err := m.extract(r)
+	if errors.Is(err, ErrUnsupportedMedia) && m.failOpen {
+		// the detected contents are still moderated
+		r.Header.Set(HeaderError, err.Error())
+		err = nil
+	}
+	if err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, ErrUnsupportedMedia) {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(contents) == 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+	v := &Verdict{ID: newID(), State: StateHold, Contents: contents}
+
+	// step2. recognize in background with a hold in async mode
+	if m.async {
+		m.hold(v)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+			defer cancel()
+			decided := m.moderate(ctx, v)
+			m.mu.Lock()
+			m.holds[v.ID] = decided
+			m.mu.Unlock()
+			if m.onDone != nil {
+				m.onDone(decided)
+			}
+		}()
+		r.Header.Set(HeaderHold, v.ID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, v)))
+		return
+	}
+
+	// step3. recognize in-line and apply the behavior
+	ctx, cancel := context.WithTimeout(r.Context(), m.timeout)
+	v = m.moderate(ctx, v)
+	cancel()
+	r = r.WithContext(context.WithValue(r.Context(), contextKey{}, v))
+	if v.Err != nil {
+		if !m.failOpen {
+			m.errorFunc(w, r, v.Err)
+			return
+		}
+		r.Header.Set(HeaderError, v.Err.Error())
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	switch m.behaviors[v.Action] {
+	case BehaviorReject:
+		m.rejectFunc(w, r, v)
+		return
+	case BehaviorTag:
+		r.Header.Set(HeaderAction, string(v.Action))
+		r.Header.Set(HeaderItems, v.itemsHeader())
+	}
+	next.ServeHTTP(w, r)
+}
+
+// moderate recognizes the contents by kind concurrently and decides the verdict, the verdict
+// passed in isn't changed, so it is safe to be read by the handler in async mode
+func (m *Middleware) moderate(ctx context.Context, held *Verdict) *Verdict {
+	v := &Verdict{ID: held.ID, State: StateDone, Contents: held.Contents, Results: make(map[string]string)}
+
+	byKind := make(map[string][]*Content)
+	for _, c := range v.Contents {
+		if m.recognizers[c.Kind] != nil {
+			byKind[c.Kind] = append(byKind[c.Kind], c)
+		}
+	}
+
+	type kindResult struct {
+		kind   string
+		result string
+		err    error
+	}
+	results := make(chan kindResult, len(byKind))
+	for kind, contents := range byKind {
+		go func(kind string, contents []*Content) {
+			result, statusCode, err := m.recognizers[kind](ctx, contents)
+			if err == nil && statusCode > 299 {
+				err = fmt.Errorf("%s recognition: status code: %d", kind, statusCode)
+			}
+			results <- kindResult{kind: kind, result: result, err: err}
+		}(kind, contents)
+	}
+
+	var (
+		p, version = m.policy()
+		decisions  = make(map[string]*policy.Decision)
+		// a result without items takes the default action of the policy
+		action = policy.ActionPass
+	)
+	v.PolicyVersion = version
+	for range byKind {
+		var kr kindResult
+		select {
+		case kr = <-results:
+		case <-ctx.Done():
+			// the recognizers without context support return later, their results are dropped
+			kr.err = ctx.Err()
+		}
+		if kr.err == nil {
+			kr.err = resultError(kr.kind, kr.result)
+		}
+		if kr.err == nil {
+			v.Results[kr.kind] = kr.result
+			var ds map[string]*policy.Decision
+			if ds, kr.err = p.Evaluate(kr.result); kr.err == nil {
+				if len(ds) == 0 && p.DefaultAction.MoreSevere(action) {
+					action = p.DefaultAction
+				}
+				for item, d := range ds {
+					decisions[item] = d
+				}
+			}
+		}
+		if kr.err != nil {
+			v.State, v.Err = StateFailed, kr.err
+			v.DecidedAt = time.Now()
+			return v
+		}
+	}
+
+	// the action of the request is the most severe one, the items TUPU names differently from
+	// the contents are counted too
+	v.Action = action
+	for _, d := range decisions {
+		v.Decisions = append(v.Decisions, d)
+		if d.Action.MoreSevere(v.Action) {
+			v.Action = d.Action
+		}
+	}
+	sort.Slice(v.Decisions, func(i, j int) bool {
+		return v.Decisions[i].Item < v.Decisions[j].Item
+	})
+	v.DecidedAt = time.Now()
+	return v
+}
+
+// resultError returns the error of a result whose code isn't 0, such as an expired secretId or
+// an exceeded quota, so it is handled by the error function instead of passed
+func resultError(kind, result string) error {
+	var head struct {
+		Code    interface{} `json:"code"`
+		Message string      `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(result), &head); err != nil {
+		return fmt.Errorf("%s recognition: invalid result: %v", kind, err)
+	}
+	switch code := head.Code.(type) {
+	case nil:
+		return nil
+	case float64:
+		if code == 0 {
+			return nil
+		}
+	case string:
+		if code == "" || code == "0" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s recognition: code %v: %s", kind, head.Code, head.Message)
+}
+
+// hold saves the verdict of async mode, and drops the decided verdicts older than the ttl
+func (m *Middleware) hold(v *Verdict) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deadline := time.Now().Add(-m.holdTTL)
+	for id, held := range m.holds {
+		if held.State != StateHold && held.DecidedAt.Before(deadline) {
+			delete(m.holds, id)
+		}
+	}
+	m.holds[v.ID] = v
+}
+
+// itemsHeader returns the actions of the contents other than pass, such as `file-a.jpg=blur, title=review`
+func (v *Verdict) itemsHeader() string {
+	items := make([]string, 0, len(v.Decisions))
+	for _, d := range v.Decisions {
+		if d.Action != policy.ActionPass {
+			items = append(items, d.Item+"="+string(d.Action))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// reject is the default reject function
+func reject(w http.ResponseWriter, r *http.Request, v *Verdict) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  "content rejected by moderation",
+		"action": v.Action,
+		"items":  v.itemsHeader(),
+	})
+}
+
+// fail is the default error function
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, "content moderation unavailable", http.StatusServiceUnavailable)
+}
+
+func newID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
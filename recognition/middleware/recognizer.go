@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/tuputech/tupu-go-sdk/recognition"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textsync"
+	"github.com/tuputech/tupu-go-sdk/recognition/video/videosync"
+)
+
+// ImageRecognizer returns the RecognizeFunc of images by the image handler
+func ImageRecognizer(h *recognition.Handler, secretID string, tasks ...string) RecognizeFunc {
+	return func(ctx context.Context, contents []*Content) (string, int, error) {
+		images := make([]*recognition.Image, len(contents))
+		for i, c := range contents {
+			images[i] = recognition.NewBinaryImage(c.Data, c.Name)
+		}
+		return h.Perform(secretID, images, nil, tasks)
+	}
+}
+
+// TextRecognizer returns the RecognizeFunc of texts by the text sync handler, the contentId
+// of a text is the name of the content
+func TextRecognizer(h *textsync.SyncHandler, secretID string) RecognizeFunc {
+	return func(ctx context.Context, contents []*Content) (string, int, error) {
+		texts := make([]textsync.TextAsyncItem, len(contents))
+		for i, c := range contents {
+			texts[i] = textsync.TextAsyncItem{Content: string(c.Data), ContentID: c.Name}
+		}
+		return h.Perform(secretID, texts)
+	}
+}
+
+// SpeechRecognizer returns the RecognizeFunc of audios by the speech sync handler
+func SpeechRecognizer(h *speechsync.SyncHandler, secretID string, tasks ...string) RecognizeFunc {
+	return func(ctx context.Context, contents []*Content) (string, int, error) {
+		return h.PerformWithBinary(secretID, binaryData(contents), tasks...)
+	}
+}
+
+// VideoRecognizer returns the RecognizeFunc of videos by the video sync handler
+func VideoRecognizer(h *videosync.SyncHandler, secretID string, optFuncs ...videosync.SyncOptFunc) RecognizeFunc {
+	return func(ctx context.Context, contents []*Content) (string, int, error) {
+		return h.PerformWithBinary(secretID, binaryData(contents), optFuncs...)
+	}
+}
+
+func binaryData(contents []*Content) map[string][]byte {
+	data := make(map[string][]byte, len(contents))
+	for _, c := range contents {
+		data[c.Name] = c.Data
+	}
+	return data
+}
@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// Decision is the action of an item and the rules fired
+	Decision struct {
+		Item   string `json:"item"`
+		Action Action `json:"action"`
+		// Rule is the winning rule, nil if the default action is taken
+		Rule *Rule `json:"rule,omitempty"`
+		// Matches is all fired rules, the winning one first
+		Matches []*Match `json:"matches,omitempty"`
+	}
+
+	// Match is a rule fired on the result of a task
+	Match struct {
+		Rule   *Rule   `json:"rule"`
+		TaskID string  `json:"taskId"`
+		Label  int     `json:"label"`
+		Rate   float64 `json:"rate"`
+		// Reason is the conditions held, such as `label 1, rate 0.93 in [0.8, +inf]`
+		Reason string `json:"reason"`
+	}
+)
+
+// Explain returns the decision and the fired rules in lines, such as
+//
+//	a.jpg: block by rule "porn-high" (priority 10)
+//	  * porn-high (priority 10): task 54bcfc6c329af61034f7c2fc label 1, rate 0.93 in [0.8, +inf] -> block
+func (d *Decision) Explain() string {
+	var b strings.Builder
+	if d.Rule == nil {
+		fmt.Fprintf(&b, "%s: %s by default, no rule fired", d.Item, d.Action)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s: %s by rule %q (priority %d)", d.Item, d.Action, d.Rule.Name, d.Rule.Priority)
+	for _, m := range d.Matches {
+		fmt.Fprintf(&b, "\n  * %s (priority %d): task %s %s -> %s", m.Rule.Name, m.Rule.Priority, m.TaskID, m.Reason, m.Rule.Action)
+	}
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+// nameKeys are the fields naming an item in the results, in order of preference
+var nameKeys = []string{"name", "contentId", "url", "videoId", "requestId"}
+
+// metaKeys are the top level fields of a result which are not tasks
+var metaKeys = map[string]bool{
+	"code":       true,
+	"message":    true,
+	"timestamp":  true,
+	"nonce":      true,
+	"customInfo": true,
+}
+
+type (
+	// Item is the result of a task for one file or text
+	Item struct {
+		TaskID string
+		// Name is the file name, contentId or url, "#<index>" if the result has no name
+		Name   string
+		Label  int
+		Rate   float64
+		Review bool
+		// Objects is the detected objects, such as logos or faces
+		Objects []*Object
+		// Texts is the OCR text and the hit keywords
+		Texts []string
+	}
+
+	// Object is a detected object in an image
+	Object struct {
+		Name string
+		Rate float64
+	}
+)
+
+// ParseItems returns the items of every task in the result json of a recognition api, the
+// items are the objects in the arrays of a task, such as "fileList" or "results"
+func ParseItems(result string) ([]*Item, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return nil, fmt.Errorf("invalid recognition result: %v", err)
+	}
+	// some apis wrap the tasks in "result"
+	if inner, ok := data["result"].(map[string]interface{}); ok {
+		data = inner
+	}
+
+	taskIDs := make([]string, 0, len(data))
+	for taskID := range data {
+		taskIDs = append(taskIDs, taskID)
+	}
+	sort.Strings(taskIDs)
+
+	var items []*Item
+	for _, taskID := range taskIDs {
+		task, ok := data[taskID].(map[string]interface{})
+		if !ok || metaKeys[taskID] {
+			continue
+		}
+		// the keys are sorted, so an unnamed item has the same "#index" name in every parse
+		keys := make([]string, 0, len(task))
+		for key := range task {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		index := 0
+		for _, key := range keys {
+			list, ok := task[key].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, elem := range list {
+				raw, ok := elem.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				items = append(items, newItem(taskID, index, raw))
+				index++
+			}
+		}
+	}
+	return items, nil
+}
+
+func newItem(taskID string, index int, raw map[string]interface{}) *Item {
+	item := &Item{TaskID: taskID, Name: fmt.Sprintf("#%d", index)}
+	for _, key := range nameKeys {
+		if name, ok := raw[key].(string); ok && name != "" {
+			item.Name = name
+			break
+		}
+	}
+	item.Label = int(tupumodel.ToFloat(raw["label"]))
+	item.Rate = tupumodel.ToFloat(raw["rate"])
+	item.Review, _ = raw["review"].(bool)
+
+	if objects, ok := raw["objects"].([]interface{}); ok {
+		for _, o := range objects {
+			object, ok := o.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := object["name"].(string)
+			if name == "" {
+				name, _ = object["label"].(string)
+			}
+			rate, ok := object["rate"]
+			if !ok {
+				rate = raw["rate"]
+			}
+			item.Objects = append(item.Objects, &Object{Name: name, Rate: tupumodel.ToFloat(rate)})
+		}
+	}
+
+	item.Texts = appendTexts(item.Texts, raw["text"])
+	item.Texts = appendTexts(item.Texts, raw["ocr"])
+	if details, ok := raw["details"].([]interface{}); ok {
+		for _, d := range details {
+			if detail, ok := d.(map[string]interface{}); ok {
+				item.Texts = appendTexts(item.Texts, detail["keyword"])
+				item.Texts = appendTexts(item.Texts, detail["text"])
+				item.Texts = appendTexts(item.Texts, detail["hint"])
+			}
+		}
+	}
+	return item
+}
+
+// appendTexts appends a string, the strings in an array, or the "text" of the objects in an array
+func appendTexts(texts []string, val interface{}) []string {
+	switch v := val.(type) {
+	case string:
+		if v != "" {
+			texts = append(texts, v)
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if m, ok := elem.(map[string]interface{}); ok {
+				texts = appendTexts(texts, m["text"])
+			} else {
+				texts = appendTexts(texts, elem)
+			}
+		}
+	}
+	return texts
+}
@@ -0,0 +1,377 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	// DefaultReloadInterval is the default interval Watch checks the source at
+	DefaultReloadInterval = 30 * time.Second
+	// DefaultHistory is the default number of snapshots kept for Rollback
+	DefaultHistory = 10
+)
+
+var (
+	// ErrNoCandidate is returned by Promote when there is no candidate policy in dry-run mode
+	ErrNoCandidate = errors.New("no candidate policy")
+	// ErrUnknownVersion is returned by Rollback when the version is not in the history
+	ErrUnknownVersion = errors.New("unknown policy version")
+)
+
+type (
+	// Snapshot is a loaded version of the policy
+	Snapshot struct {
+		Policy *Policy
+		// Version is the version in the policy, or the hash prefix if the policy has no version
+		Version string
+		// Hash is the hex sha256 of the definition
+		Hash     string
+		Source   string
+		LoadedAt time.Time
+	}
+
+	// Disagreement is an item decided differently by the current and the candidate policy
+	Disagreement struct {
+		Item             string
+		CurrentVersion   string
+		CandidateVersion string
+		Current          *Decision
+		Candidate        *Decision
+	}
+
+	// Loader loads the policy from a source and swaps it atomically when the source changes,
+	// an invalid policy is rejected and the current one is kept. In dry-run mode a new policy
+	// becomes the candidate, it is evaluated alongside the current one until it is promoted
+	Loader struct {
+		source         Source
+		dryRun         bool
+		historySize    int
+		onChange       func(old, new *Snapshot)
+		onError        func(error)
+		onDisagreement func(*Disagreement)
+		unmarshal      UnmarshalFunc
+
+		current   atomic.Value // *Snapshot
+		candidate atomic.Value // *Snapshot, nil if there is no candidate
+
+		mu      sync.Mutex
+		history []*Snapshot
+		// sourceHash is the hash of the latest definition fetched from the source
+		sourceHash string
+		// pinnedHash is the sourceHash when Rollback is called, the definition is not
+		// loaded again until the source changes
+		pinnedHash string
+	}
+
+	// LoaderOptFunc is the optional setting of Loader
+	LoaderOptFunc func(*Loader)
+)
+
+// WithDryRun evaluates the new policies alongside the current one instead of swapping them in
+func WithDryRun(dryRun bool) LoaderOptFunc {
+	return func(l *Loader) {
+		l.dryRun = dryRun
+	}
+}
+
+// WithHistory sets the number of snapshots kept for Rollback
+func WithHistory(n int) LoaderOptFunc {
+	return func(l *Loader) {
+		if n > 0 {
+			l.historySize = n
+		}
+	}
+}
+
+// WithChangeFunc sets the function called after the current or the candidate policy is changed,
+// old is nil for the first candidate
+func WithChangeFunc(onChange func(old, new *Snapshot)) LoaderOptFunc {
+	return func(l *Loader) {
+		l.onChange = onChange
+	}
+}
+
+// WithErrorFunc sets the function called when Watch fails to reload the policy
+func WithErrorFunc(onError func(error)) LoaderOptFunc {
+	return func(l *Loader) {
+		l.onError = onError
+	}
+}
+
+// WithDisagreementFunc sets the function called for every disagreement in dry-run mode
+func WithDisagreementFunc(onDisagreement func(*Disagreement)) LoaderOptFunc {
+	return func(l *Loader) {
+		l.onDisagreement = onDisagreement
+	}
+}
+
+// WithUnmarshal sets the function to decode the policy, json.Unmarshal by default,
+// pass the Unmarshal of a YAML library to load a policy in YAML
+func WithUnmarshal(unmarshal UnmarshalFunc) LoaderOptFunc {
+	return func(l *Loader) {
+		if unmarshal != nil {
+			l.unmarshal = unmarshal
+		}
+	}
+}
+
+// NewLoader is an initializer for a Loader, the first policy is loaded from the source and
+// must be valid
+func NewLoader(ctx context.Context, source Source, optFuncs ...LoaderOptFunc) (*Loader, error) {
+	if source == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	l := &Loader{source: source, historySize: DefaultHistory, unmarshal: json.Unmarshal}
+	for _, setConf := range optFuncs {
+		setConf(l)
+	}
+
+	data, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load policy from %s: %w", source, err)
+	}
+	snap, err := l.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	l.current.Store(snap)
+	l.candidate.Store((*Snapshot)(nil))
+	l.history = []*Snapshot{snap}
+	l.sourceHash = snap.Hash
+	return l, nil
+}
+
+// Current returns the snapshot of the current policy
+func (l *Loader) Current() *Snapshot {
+	return l.current.Load().(*Snapshot)
+}
+
+// Policy returns the current policy
+func (l *Loader) Policy() *Policy {
+	return l.Current().Policy
+}
+
+// Candidate returns the snapshot of the candidate policy in dry-run mode, nil if there is none
+func (l *Loader) Candidate() *Snapshot {
+	return l.candidate.Load().(*Snapshot)
+}
+
+// History returns the loaded snapshots, the oldest first
+func (l *Loader) History() []*Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*Snapshot(nil), l.history...)
+}
+
+// Reload fetches the source and swaps in the policy if it is changed and valid, it reports
+// whether the current or the candidate policy is changed
+func (l *Loader) Reload(ctx context.Context) (bool, error) {
+	data, err := l.source.Fetch(ctx)
+	if errors.Is(err, ErrNotModified) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load policy from %s: %w", l.source, err)
+	}
+
+	hash := hashOf(data)
+	if l.pinned(hash) {
+		return false, nil
+	}
+	if hash == l.Current().Hash {
+		// a reverted definition drops the candidate
+		if l.dryRun && l.Candidate() != nil {
+			l.candidate.Store((*Snapshot)(nil))
+			return true, nil
+		}
+		return false, nil
+	}
+	if cand := l.Candidate(); cand != nil && cand.Hash == hash {
+		return false, nil
+	}
+
+	snap, err := l.parse(data)
+	if err != nil {
+		return false, err
+	}
+	if l.dryRun {
+		old := l.Candidate()
+		l.candidate.Store(snap)
+		l.notify(old, snap)
+		return true, nil
+	}
+	l.swap(snap)
+	return true, nil
+}
+
+// Watch reloads the policy at every interval until ctx is done, the errors are passed to the
+// function set by WithErrorFunc
+func (l *Loader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.Reload(ctx); err != nil && l.onError != nil && ctx.Err() == nil {
+				l.onError(err)
+			}
+		}
+	}
+}
+
+// Promote swaps in the candidate policy of dry-run mode
+func (l *Loader) Promote() error {
+	cand := l.Candidate()
+	if cand == nil {
+		return ErrNoCandidate
+	}
+	l.candidate.Store((*Snapshot)(nil))
+	l.swap(cand)
+	return nil
+}
+
+// Rollback swaps in the snapshot of the version in the history, the version is kept
+// by Reload until the definition in the source is changed
+func (l *Loader) Rollback(version string) error {
+	l.mu.Lock()
+	var snap *Snapshot
+	for i := len(l.history) - 1; i >= 0; i-- {
+		if l.history[i].Version == version {
+			snap = l.history[i]
+			break
+		}
+	}
+	if snap != nil {
+		l.pinnedHash = l.sourceHash
+	}
+	l.mu.Unlock()
+	if snap == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+	}
+	l.swap(snap)
+	return nil
+}
+
+// pinned records hash as the latest definition of the source, and reports whether it is
+// the definition rolled back from
+func (l *Loader) pinned(hash string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sourceHash = hash
+	if hash == l.pinnedHash {
+		return true
+	}
+	l.pinnedHash = ""
+	return false
+}
+
+// Evaluate decides the items of the result by the current policy. In dry-run mode the candidate
+// policy decides them too, and the items decided differently are returned as disagreements
+func (l *Loader) Evaluate(result string) (map[string]*Decision, []*Disagreement, error) {
+	current, cand := l.Current(), l.Candidate()
+	decisions, err := current.Policy.Evaluate(result)
+	if err != nil || cand == nil {
+		return decisions, nil, err
+	}
+
+	candDecisions, err := cand.Policy.Evaluate(result)
+	if err != nil {
+		return decisions, nil, err
+	}
+	return decisions, l.compare(current, cand, decisions, candDecisions), nil
+}
+
+func (l *Loader) compare(current, cand *Snapshot, decisions, candDecisions map[string]*Decision) []*Disagreement {
+	var disagreements []*Disagreement
+	for item, d := range decisions {
+		cd := candDecisions[item]
+		if cd == nil || cd.Action == d.Action {
+			continue
+		}
+		disagreement := &Disagreement{
+			Item:             item,
+			CurrentVersion:   current.Version,
+			CandidateVersion: cand.Version,
+			Current:          d,
+			Candidate:        cd,
+		}
+		disagreements = append(disagreements, disagreement)
+		if l.onDisagreement != nil {
+			l.onDisagreement(disagreement)
+		}
+	}
+	return disagreements
+}
+
+// String returns the disagreement in a line, such as
+// `a.jpg: pass by v3 (default), block by v4 (porn-block)`
+func (d *Disagreement) String() string {
+	return fmt.Sprintf("%s: %s by %s (%s), %s by %s (%s)", d.Item,
+		d.Current.Action, d.CurrentVersion, ruleName(d.Current),
+		d.Candidate.Action, d.CandidateVersion, ruleName(d.Candidate))
+}
+
+func (l *Loader) parse(data []byte) (*Snapshot, error) {
+	p, err := ParsePolicyWith(data, l.unmarshal)
+	if err != nil {
+		return nil, fmt.Errorf("load policy from %s: %w", l.source, err)
+	}
+	hash := hashOf(data)
+	version := p.Version
+	if version == "" {
+		version = hash[:12]
+	}
+	return &Snapshot{
+		Policy:   p,
+		Version:  version,
+		Hash:     hash,
+		Source:   l.source.String(),
+		LoadedAt: time.Now(),
+	}, nil
+}
+
+func (l *Loader) swap(snap *Snapshot) {
+	l.mu.Lock()
+	old := l.Current()
+	l.current.Store(snap)
+	l.history = append(l.history, snap)
+	if len(l.history) > l.historySize {
+		l.history = l.history[len(l.history)-l.historySize:]
+	}
+	l.mu.Unlock()
+	l.notify(old, snap)
+}
+
+func (l *Loader) notify(old, snap *Snapshot) {
+	if l.onChange != nil {
+		l.onChange(old, snap)
+	}
+}
+
+func ruleName(d *Decision) string {
+	if d.Rule == nil {
+		return "default"
+	}
+	return d.Rule.Name
+}
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
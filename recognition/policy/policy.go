@@ -0,0 +1,305 @@
+// Package policy provide a moderation policy engine which maps recognition results to actions
+// by declarative rules
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	// ActionPass lets the content through
+	ActionPass Action = "pass"
+	// ActionReview sends the content to human review
+	ActionReview Action = "review"
+	// ActionBlur publishes the content blurred or masked
+	ActionBlur Action = "blur"
+	// ActionBlock rejects the content
+	ActionBlock Action = "block"
+
+	// AnyTask matches the results of all tasks
+	AnyTask = "*"
+)
+
+// severity orders the actions, the more severe action wins a tie of priority
+var severity = map[Action]int{
+	ActionPass:   0,
+	ActionReview: 1,
+	ActionBlur:   2,
+	ActionBlock:  3,
+}
+
+// ErrInvalidPolicy is wrapped by the errors of Validate
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+type (
+	// Action is what to do with the content
+	Action string
+
+	// Policy is a set of rules, the item matched by no rule takes DefaultAction.
+	// The yaml tags let a YAML library decode the same format by ParsePolicyWith
+	Policy struct {
+		Name          string  `json:"name,omitempty" yaml:"name,omitempty"`
+		Version       string  `json:"version,omitempty" yaml:"version,omitempty"`
+		DefaultAction Action  `json:"defaultAction,omitempty" yaml:"defaultAction,omitempty"`
+		Rules         []*Rule `json:"rules" yaml:"rules"`
+	}
+
+	// Rule maps the result of a task to an action, all the conditions set must hold. A rule
+	// of higher priority wins, and the more severe action wins a tie
+	Rule struct {
+		Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+		Priority int    `json:"priority,omitempty" yaml:"priority,omitempty"`
+		// Task is the task id, empty or "*" matches all tasks
+		Task string `json:"task,omitempty" yaml:"task,omitempty"`
+		// Labels matches any of the labels, empty matches all labels
+		Labels  []int    `json:"labels,omitempty" yaml:"labels,omitempty"`
+		MinRate *float64 `json:"minRate,omitempty" yaml:"minRate,omitempty"`
+		MaxRate *float64 `json:"maxRate,omitempty" yaml:"maxRate,omitempty"`
+		Review  *bool    `json:"review,omitempty" yaml:"review,omitempty"`
+		// Objects matches any detected object by name, the rate conditions apply to the object
+		Objects []string `json:"objects,omitempty" yaml:"objects,omitempty"`
+		// Keywords matches any keyword in the OCR text or the hit details, case is ignored
+		Keywords []string `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+		Action   Action   `json:"action" yaml:"action"`
+
+		index int
+	}
+)
+
+// UnmarshalFunc decodes data into v, such as json.Unmarshal or the Unmarshal of a YAML library
+type UnmarshalFunc func(data []byte, v interface{}) error
+
+// ParsePolicy parses and validates a policy in JSON
+func ParsePolicy(data []byte) (*Policy, error) {
+	return ParsePolicyWith(data, json.Unmarshal)
+}
+
+// ParsePolicyWith parses and validates a policy decoded by unmarshal, a policy in YAML
+// is parsed by passing the Unmarshal of a YAML library, such as yaml.Unmarshal
+func ParsePolicyWith(data []byte, unmarshal UnmarshalFunc) (*Policy, error) {
+	if unmarshal == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	p := new(Policy)
+	if err := unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadPolicy reads and parses the policy file at path
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// Validate checks the rules and fills the defaults, it must be called on a policy not built
+// by ParsePolicy before Evaluate. The rules without name are named by their position
+func (p *Policy) Validate() error {
+	if p.DefaultAction == "" {
+		p.DefaultAction = ActionPass
+	}
+	if !p.DefaultAction.Valid() {
+		return fmt.Errorf("%w: unknown default action %q", ErrInvalidPolicy, p.DefaultAction)
+	}
+
+	names := make(map[string]bool, len(p.Rules))
+	for i, rule := range p.Rules {
+		if rule == nil {
+			return fmt.Errorf("%w: rule %d is empty", ErrInvalidPolicy, i)
+		}
+		rule.index = i
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule#%d", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate rule name %q", ErrInvalidPolicy, rule.Name)
+		}
+		names[rule.Name] = true
+
+		if !rule.Action.Valid() {
+			return fmt.Errorf("%w: rule %q: unknown action %q", ErrInvalidPolicy, rule.Name, rule.Action)
+		}
+		if rule.MinRate != nil && rule.MaxRate != nil && *rule.MinRate > *rule.MaxRate {
+			return fmt.Errorf("%w: rule %q: minRate %v > maxRate %v", ErrInvalidPolicy, rule.Name, *rule.MinRate, *rule.MaxRate)
+		}
+	}
+	return nil
+}
+
+// Valid reports whether the action is one of pass, review, blur and block
+func (a Action) Valid() bool {
+	_, ok := severity[a]
+	return ok
+}
+
+// MoreSevere reports whether a is more severe than b
+func (a Action) MoreSevere(b Action) bool {
+	return severity[a] > severity[b]
+}
+
+// Evaluate parses the result json of a recognition api and decides every item in it, the
+// key is the name of the item, such as the file name or the contentId
+func (p *Policy) Evaluate(result string) (map[string]*Decision, error) {
+	items, err := ParseItems(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		decisions = make(map[string]*Decision)
+		byName    = make(map[string][]*Item)
+		order     []string
+	)
+	for _, item := range items {
+		if _, ok := byName[item.Name]; !ok {
+			order = append(order, item.Name)
+		}
+		byName[item.Name] = append(byName[item.Name], item)
+	}
+	for _, name := range order {
+		decisions[name] = p.Decide(name, byName[name])
+	}
+	return decisions, nil
+}
+
+// Decide returns the decision of the results of an item in all tasks
+func (p *Policy) Decide(name string, items []*Item) *Decision {
+	d := &Decision{Item: name, Action: p.DefaultAction}
+	for _, rule := range p.Rules {
+		for _, item := range items {
+			if match := rule.match(item); match != nil {
+				d.Matches = append(d.Matches, match)
+			}
+		}
+	}
+	if len(d.Matches) == 0 {
+		return d
+	}
+
+	sort.SliceStable(d.Matches, func(i, j int) bool {
+		a, b := d.Matches[i].Rule, d.Matches[j].Rule
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.Action != b.Action {
+			return a.Action.MoreSevere(b.Action)
+		}
+		return a.index < b.index
+	})
+	d.Rule = d.Matches[0].Rule
+	d.Action = d.Rule.Action
+	return d
+}
+
+// match returns the match of the rule on the item, nil if the rule doesn't match
+func (rule *Rule) match(item *Item) *Match {
+	if rule.Task != "" && rule.Task != AnyTask && rule.Task != item.TaskID {
+		return nil
+	}
+	if len(rule.Labels) > 0 && !containsInt(rule.Labels, item.Label) {
+		return nil
+	}
+	if rule.Review != nil && *rule.Review != item.Review {
+		return nil
+	}
+
+	var reasons []string
+	if item.Label != 0 || len(rule.Labels) > 0 {
+		reasons = append(reasons, fmt.Sprintf("label %d", item.Label))
+	}
+	if rule.Review != nil {
+		reasons = append(reasons, fmt.Sprintf("review %v", item.Review))
+	}
+
+	if len(rule.Objects) > 0 {
+		object := rule.matchObject(item)
+		if object == nil {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("object %q rate %.4g", object.Name, object.Rate))
+	} else {
+		if !rule.rateInRange(item.Rate) {
+			return nil
+		}
+		if rule.MinRate != nil || rule.MaxRate != nil {
+			reasons = append(reasons, fmt.Sprintf("rate %.4g in %s", item.Rate, rule.rateRange()))
+		}
+	}
+
+	if len(rule.Keywords) > 0 {
+		keyword := rule.matchKeyword(item)
+		if keyword == "" {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("keyword %q", keyword))
+	}
+
+	return &Match{
+		Rule:   rule,
+		TaskID: item.TaskID,
+		Label:  item.Label,
+		Rate:   item.Rate,
+		Reason: strings.Join(reasons, ", "),
+	}
+}
+
+func (rule *Rule) matchObject(item *Item) *Object {
+	for _, object := range item.Objects {
+		for _, name := range rule.Objects {
+			if strings.EqualFold(object.Name, name) && rule.rateInRange(object.Rate) {
+				return object
+			}
+		}
+	}
+	return nil
+}
+
+func (rule *Rule) matchKeyword(item *Item) string {
+	for _, text := range item.Texts {
+		lower := strings.ToLower(text)
+		for _, keyword := range rule.Keywords {
+			if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+				return keyword
+			}
+		}
+	}
+	return ""
+}
+
+func (rule *Rule) rateInRange(rate float64) bool {
+	return (rule.MinRate == nil || rate >= *rule.MinRate) && (rule.MaxRate == nil || rate <= *rule.MaxRate)
+}
+
+func (rule *Rule) rateRange() string {
+	low, high := "-inf", "+inf"
+	if rule.MinRate != nil {
+		low = fmt.Sprint(*rule.MinRate)
+	}
+	if rule.MaxRate != nil {
+		high = fmt.Sprint(*rule.MaxRate)
+	}
+	return "[" + low + ", " + high + "]"
+}
+
+func containsInt(list []int, v int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNotModified is returned by Source.Fetch when the policy is not changed since the last fetch
+var ErrNotModified = errors.New("policy not modified")
+
+type (
+	// Source provides the policy definition in JSON
+	Source interface {
+		// Fetch returns the policy definition, or ErrNotModified if the source can tell the
+		// definition is not changed since the last fetch
+		Fetch(ctx context.Context) ([]byte, error)
+		// String names the source in the snapshots and errors
+		String() string
+	}
+
+	// FileSource reads the policy from a file, the file is not read again while its
+	// modification time and size are not changed
+	FileSource struct {
+		Path string
+
+		mu      sync.Mutex
+		modTime time.Time
+		size    int64
+	}
+
+	// HTTPSource gets the policy from a url, the ETag and Last-Modified headers of the
+	// response are sent back to skip the body of an unchanged policy
+	HTTPSource struct {
+		URL    string
+		Client *http.Client
+		// Header is added to the requests, such as Authorization
+		Header http.Header
+
+		mu           sync.Mutex
+		etag         string
+		lastModified string
+	}
+)
+
+// NewFileSource is an initializer for a FileSource
+func NewFileSource(path string) *FileSource {
+	return &FileSource{Path: path}
+}
+
+// Fetch reads the file if it is changed
+func (s *FileSource) Fetch(ctx context.Context) ([]byte, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil, ErrNotModified
+	}
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return data, nil
+}
+
+func (s *FileSource) String() string {
+	return "file:" + s.Path
+}
+
+// NewHTTPSource is an initializer for a HTTPSource, http.DefaultClient is used if client is nil
+func NewHTTPSource(url string, client *http.Client) *HTTPSource {
+	return &HTTPSource{URL: url, Client: client}
+}
+
+// Fetch gets the policy with a conditional request
+func (s *HTTPSource) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range s.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	s.mu.Lock()
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	s.mu.Unlock()
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetch policy from %s: status code: %d", s.URL, resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.etag, s.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	s.mu.Unlock()
+	return data, nil
+}
+
+func (s *HTTPSource) String() string {
+	return s.URL
+}
@@ -0,0 +1,63 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/tuputech/tupu-go-sdk/recognition/policy"
+)
+
+// EnqueueResult adds the items of a recognition result which need review, they are the items
+// flagged by TUPU with review, and the items decided to review by the policy if decisions is not
+// nil. It returns the number of items passed to the queue, including the ones already in it.
+// The unnamed items, such as "#0", have the same name in every result, so their IDs are taken
+// from the result too
+func EnqueueResult(q Queue, kind, result string, decisions map[string]*policy.Decision) (int, error) {
+	items, err := policy.ParseItems(result)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		n      = 0
+		digest string
+	)
+	for _, item := range items {
+		reason := ""
+		if item.Review {
+			reason = "review flag of TUPU"
+		}
+		if d := decisions[item.Name]; d != nil && d.Action == policy.ActionReview {
+			// the default action applies to the items of all tasks, a rule to the task it fired on
+			if d.Rule == nil || (len(d.Matches) > 0 && d.Matches[0].TaskID == item.TaskID) {
+				reason = d.Explain()
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		id := ""
+		if strings.HasPrefix(item.Name, "#") {
+			if digest == "" {
+				sum := sha256.Sum256([]byte(result))
+				digest = hex.EncodeToString(sum[:])
+			}
+			id = ItemID(kind, digest+item.Name, item.TaskID)
+		}
+		if err = q.Enqueue(&Item{
+			ID:     id,
+			Kind:   kind,
+			Source: item.Name,
+			TaskID: item.TaskID,
+			Label:  item.Label,
+			Rate:   item.Rate,
+			Reason: reason,
+		}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,127 @@
+package review
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type (
+	// Sample is a decided item as labeled data, Label and Rate are given by TUPU and
+	// ReviewedLabel is given by the reviewer
+	Sample struct {
+		ID            string    `json:"id"`
+		Kind          string    `json:"kind,omitempty"`
+		Source        string    `json:"source"`
+		TaskID        string    `json:"taskId,omitempty"`
+		Label         int       `json:"label"`
+		Rate          float64   `json:"rate"`
+		ReviewedLabel int       `json:"reviewedLabel"`
+		Action        string    `json:"action,omitempty"`
+		Reviewer      string    `json:"reviewer"`
+		DecidedAt     time.Time `json:"decidedAt"`
+	}
+
+	// ThresholdPoint is the precision and recall of taking a label when its rate is at least
+	// Threshold, measured on the samples
+	ThresholdPoint struct {
+		Threshold float64 `json:"threshold"`
+		Precision float64 `json:"precision"`
+		Recall    float64 `json:"recall"`
+		// TP, FP and FN are the true positives, false positives and false negatives
+		TP int `json:"tp"`
+		FP int `json:"fp"`
+		FN int `json:"fn"`
+	}
+)
+
+// Samples returns the decided items of the queue as samples
+func Samples(q Queue) ([]*Sample, error) {
+	items, err := q.List(StateDecided)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]*Sample, 0, len(items))
+	for _, item := range items {
+		samples = append(samples, &Sample{
+			ID:            item.ID,
+			Kind:          item.Kind,
+			Source:        item.Source,
+			TaskID:        item.TaskID,
+			Label:         item.Label,
+			Rate:          item.Rate,
+			ReviewedLabel: item.Decision.Label,
+			Action:        item.Decision.Action,
+			Reviewer:      item.Decision.Reviewer,
+			DecidedAt:     item.Decision.DecidedAt,
+		})
+	}
+	return samples, nil
+}
+
+// ExportSamples writes the samples as json lines
+func ExportSamples(w io.Writer, samples []*Sample) error {
+	encoder := json.NewEncoder(w)
+	for _, sample := range samples {
+		if err := encoder.Encode(sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep measures the thresholds of the label of the task on the samples, all tasks if taskID is
+// empty. The thresholds are 0, 0.05, ..., 1 if thresholds is empty
+func Sweep(samples []*Sample, taskID string, label int, thresholds []float64) []ThresholdPoint {
+	if len(thresholds) == 0 {
+		for i := 0; i <= 20; i++ {
+			thresholds = append(thresholds, float64(i)/20)
+		}
+	}
+
+	points := make([]ThresholdPoint, 0, len(thresholds))
+	for _, threshold := range thresholds {
+		point := ThresholdPoint{Threshold: threshold}
+		for _, sample := range samples {
+			if taskID != "" && sample.TaskID != taskID {
+				continue
+			}
+			predicted := sample.Label == label && sample.Rate >= threshold
+			actual := sample.ReviewedLabel == label
+			switch {
+			case predicted && actual:
+				point.TP++
+			case predicted:
+				point.FP++
+			case actual:
+				point.FN++
+			}
+		}
+		if point.TP+point.FP > 0 {
+			point.Precision = float64(point.TP) / float64(point.TP+point.FP)
+		}
+		if point.TP+point.FN > 0 {
+			point.Recall = float64(point.TP) / float64(point.TP+point.FN)
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// SuggestThreshold returns the point of the highest recall whose precision is at least
+// minPrecision, false if there is none
+func SuggestThreshold(points []ThresholdPoint, minPrecision float64) (ThresholdPoint, bool) {
+	var (
+		best  ThresholdPoint
+		found bool
+	)
+	for _, point := range points {
+		if point.TP == 0 || point.Precision < minPrecision {
+			continue
+		}
+		if !found || point.Recall > best.Recall || (point.Recall == best.Recall && point.Threshold > best.Threshold) {
+			best, found = point, true
+		}
+	}
+	return best, found
+}
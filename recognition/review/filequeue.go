@@ -0,0 +1,194 @@
+package review
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+// compact the log on open when it has more entries than items * compactRatio
+const compactRatio = 4
+
+// FileQueue is a Queue backed by an append-only log file, every changed item is appended as a
+// json line and the log is replayed into memory when the queue is opened
+type FileQueue struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	entries int
+	state   *queueState
+	// SyncWrite calls fsync after every change if it is true
+	SyncWrite bool
+}
+
+// OpenFileQueue opens or creates the log file at path
+func OpenFileQueue(path string, optFuncs ...QueueOptFunc) (*FileQueue, error) {
+	if tupuerror.StringIsEmpty(path) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	fq := &FileQueue{path: path, state: newQueueState(optFuncs)}
+	if err := fq.replay(); err != nil {
+		return nil, err
+	}
+	if fq.entries > compactRatio*len(fq.state.items) {
+		if err := fq.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fq.file = file
+	return fq, nil
+}
+
+// Enqueue implements Queue
+func (fq *FileQueue) Enqueue(item *Item) error {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	added, err := fq.state.enqueue(item)
+	if err != nil || added == nil {
+		return err
+	}
+	return fq.append(added)
+}
+
+// Claim implements Queue
+func (fq *FileQueue) Claim(reviewer string, n int) ([]*Item, error) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	claimed, err := fq.state.claim(reviewer, n)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range claimed {
+		if err = fq.append(item); err != nil {
+			return nil, err
+		}
+	}
+	return claimed, nil
+}
+
+// Release implements Queue
+func (fq *FileQueue) Release(id string) error {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	item, err := fq.state.release(id)
+	if err != nil {
+		return err
+	}
+	return fq.append(item)
+}
+
+// Decide implements Queue
+func (fq *FileQueue) Decide(id string, decision *Decision) error {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	item, err := fq.state.decide(id, decision)
+	if err != nil {
+		return err
+	}
+	return fq.append(item)
+}
+
+// Get implements Queue
+func (fq *FileQueue) Get(id string) (*Item, error) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.state.get(id)
+}
+
+// List implements Queue
+func (fq *FileQueue) List(state string) ([]*Item, error) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.state.list(state), nil
+}
+
+// Close implements Queue
+func (fq *FileQueue) Close() error {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.file.Close()
+}
+
+func (fq *FileQueue) append(item *Item) error {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if _, err = fq.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	fq.entries++
+	if fq.SyncWrite {
+		return fq.file.Sync()
+	}
+	return nil
+}
+
+func (fq *FileQueue) replay() error {
+	file, err := os.Open(fq.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var item Item
+			// a broken line is left by a crash while writing, it is skipped
+			if json.Unmarshal(line, &item) == nil && item.ID != "" {
+				fq.state.items[item.ID] = &item
+				fq.entries++
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (fq *FileQueue) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(fq.path), filepath.Base(fq.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for _, item := range fq.state.list("") {
+		line, _ := json.Marshal(item)
+		writer.Write(append(line, '\n'))
+	}
+	if err = writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), fq.path); err != nil {
+		return err
+	}
+	fq.entries = len(fq.state.items)
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package review provide a human review queue for the items whose recognition results need
+// review, the decisions of reviewers are exported as labeled data for threshold tuning
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	// StatePending means the item is waiting for a reviewer
+	StatePending = "pending"
+	// StateClaimed means the item is being reviewed, the claim expires after the lease
+	StateClaimed = "claimed"
+	// StateDecided means the reviewer has decided the item
+	StateDecided = "decided"
+
+	// DefaultLease is the default time a claimed item is kept for the reviewer
+	DefaultLease = 30 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned when the item doesn't exist
+	ErrNotFound = errors.New("review item not found")
+	// ErrAlreadyDecided is returned when the item is decided already
+	ErrAlreadyDecided = errors.New("review item is already decided")
+	// ErrClaimedByOther is returned when the item is claimed by another reviewer
+	ErrClaimedByOther = errors.New("review item is claimed by another reviewer")
+)
+
+type (
+	// Item is a content whose recognition result needs review
+	Item struct {
+		// ID is generated from Kind, Source and TaskID if it is empty, so an item of a source which
+		// isn't unique, such as "#0" of an unnamed item, needs an ID
+		ID string `json:"id"`
+		// Kind is the kind of the content, such as image, text, speech or video
+		Kind string `json:"kind,omitempty"`
+		// Source is the url, file name or contentId of the content
+		Source string  `json:"source"`
+		TaskID string  `json:"taskId,omitempty"`
+		Label  int     `json:"label"`
+		Rate   float64 `json:"rate"`
+		// Reason is why the item needs review, such as the fired policy rule
+		Reason string `json:"reason,omitempty"`
+		// Result is the raw recognition result, optional
+		Result string            `json:"result,omitempty"`
+		Extra  map[string]string `json:"extra,omitempty"`
+
+		State     string    `json:"state"`
+		Reviewer  string    `json:"reviewer,omitempty"`
+		ClaimedAt time.Time `json:"claimedAt,omitempty"`
+		Decision  *Decision `json:"decision,omitempty"`
+		CreatedAt time.Time `json:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+
+	// Decision is the verdict of a reviewer
+	Decision struct {
+		Reviewer string `json:"reviewer"`
+		// Label is the correct label of the task, in the same meaning as the label of TUPU
+		Label int `json:"label"`
+		// Action is what is done with the content, such as pass or block
+		Action    string    `json:"action,omitempty"`
+		Note      string    `json:"note,omitempty"`
+		DecidedAt time.Time `json:"decidedAt"`
+	}
+
+	// Queue is the interface of a review queue
+	Queue interface {
+		// Enqueue adds a pending item, an item of the same ID is kept as it is
+		Enqueue(item *Item) error
+		// Claim assigns at most n pending items to the reviewer, the oldest first. The items
+		// claimed longer than the lease are claimed again
+		Claim(reviewer string, n int) ([]*Item, error)
+		// Release puts a claimed item back to pending
+		Release(id string) error
+		// Decide records the decision of the item
+		Decide(id string, decision *Decision) error
+		// Get returns a copy of the item, ErrNotFound if it doesn't exist
+		Get(id string) (*Item, error)
+		// List returns the items in state sorted by CreatedAt, all items if state is empty
+		List(state string) ([]*Item, error)
+		// Close releases the resource of the queue
+		Close() error
+	}
+
+	// QueueOptFunc is the optional setting of the queues
+	QueueOptFunc func(*queueState)
+
+	// MemoryQueue is a Queue which only lives in memory
+	MemoryQueue struct {
+		mu    sync.Mutex
+		state *queueState
+	}
+
+	// queueState is the items and the operations shared by the queues, the caller holds the lock
+	queueState struct {
+		lease time.Duration
+		now   func() time.Time
+		items map[string]*Item
+	}
+)
+
+// WithLease sets the time a claimed item is kept for the reviewer
+func WithLease(lease time.Duration) QueueOptFunc {
+	return func(s *queueState) {
+		if lease > 0 {
+			s.lease = lease
+		}
+	}
+}
+
+// ItemID returns the default ID of an item
+func ItemID(kind, source, taskID string) string {
+	sum := sha256.Sum256([]byte(kind + "\n" + source + "\n" + taskID))
+	return hex.EncodeToString(sum[:12])
+}
+
+// Clone returns a deep copy of the item
+func (item *Item) Clone() *Item {
+	c := *item
+	if item.Extra != nil {
+		c.Extra = make(map[string]string, len(item.Extra))
+		for k, v := range item.Extra {
+			c.Extra[k] = v
+		}
+	}
+	if item.Decision != nil {
+		d := *item.Decision
+		c.Decision = &d
+	}
+	return &c
+}
+
+// NewMemoryQueue is an initializer for a MemoryQueue
+func NewMemoryQueue(optFuncs ...QueueOptFunc) *MemoryQueue {
+	return &MemoryQueue{state: newQueueState(optFuncs)}
+}
+
+// Enqueue implements Queue
+func (mq *MemoryQueue) Enqueue(item *Item) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	_, err := mq.state.enqueue(item)
+	return err
+}
+
+// Claim implements Queue
+func (mq *MemoryQueue) Claim(reviewer string, n int) ([]*Item, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return mq.state.claim(reviewer, n)
+}
+
+// Release implements Queue
+func (mq *MemoryQueue) Release(id string) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	_, err := mq.state.release(id)
+	return err
+}
+
+// Decide implements Queue
+func (mq *MemoryQueue) Decide(id string, decision *Decision) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	_, err := mq.state.decide(id, decision)
+	return err
+}
+
+// Get implements Queue
+func (mq *MemoryQueue) Get(id string) (*Item, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return mq.state.get(id)
+}
+
+// List implements Queue
+func (mq *MemoryQueue) List(state string) ([]*Item, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	return mq.state.list(state), nil
+}
+
+// Close implements Queue
+func (mq *MemoryQueue) Close() error {
+	return nil
+}
+
+func newQueueState(optFuncs []QueueOptFunc) *queueState {
+	s := &queueState{
+		lease: DefaultLease,
+		now:   time.Now,
+		items: make(map[string]*Item),
+	}
+	for _, setConf := range optFuncs {
+		setConf(s)
+	}
+	return s
+}
+
+// enqueue returns the added item, nil if the item exists
+func (s *queueState) enqueue(item *Item) (*Item, error) {
+	if item == nil || tupuerror.StringIsEmpty(item.Source) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	item = item.Clone()
+	if item.ID == "" {
+		item.ID = ItemID(item.Kind, item.Source, item.TaskID)
+	}
+	if _, ok := s.items[item.ID]; ok {
+		return nil, nil
+	}
+	now := s.now()
+	item.State, item.Reviewer, item.ClaimedAt, item.Decision = StatePending, "", time.Time{}, nil
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+	item.UpdatedAt = now
+	s.items[item.ID] = item
+	return item.Clone(), nil
+}
+
+func (s *queueState) claim(reviewer string, n int) ([]*Item, error) {
+	if tupuerror.StringIsEmpty(reviewer) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	now := s.now()
+	var claimed []*Item
+	for _, item := range s.sorted() {
+		if len(claimed) >= n {
+			break
+		}
+		if item.State == StatePending || (item.State == StateClaimed && s.expired(item, now)) {
+			item.State, item.Reviewer, item.ClaimedAt, item.UpdatedAt = StateClaimed, reviewer, now, now
+			claimed = append(claimed, item.Clone())
+		}
+	}
+	return claimed, nil
+}
+
+func (s *queueState) release(id string) (*Item, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if item.State != StateClaimed {
+		return item.Clone(), nil
+	}
+	item.State, item.Reviewer, item.ClaimedAt, item.UpdatedAt = StatePending, "", time.Time{}, s.now()
+	return item.Clone(), nil
+}
+
+func (s *queueState) decide(id string, decision *Decision) (*Item, error) {
+	if decision == nil || tupuerror.StringIsEmpty(decision.Reviewer) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	item, ok := s.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	now := s.now()
+	switch {
+	case item.State == StateDecided:
+		return nil, ErrAlreadyDecided
+	case item.State == StateClaimed && item.Reviewer != decision.Reviewer && !s.expired(item, now):
+		return nil, fmt.Errorf("%w: %s", ErrClaimedByOther, item.Reviewer)
+	}
+	d := *decision
+	if d.DecidedAt.IsZero() {
+		d.DecidedAt = now
+	}
+	item.State, item.Reviewer, item.Decision, item.UpdatedAt = StateDecided, d.Reviewer, &d, now
+	return item.Clone(), nil
+}
+
+func (s *queueState) get(id string) (*Item, error) {
+	item, ok := s.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return item.Clone(), nil
+}
+
+func (s *queueState) list(state string) []*Item {
+	var list []*Item
+	for _, item := range s.sorted() {
+		if len(state) == 0 || item.State == state {
+			list = append(list, item.Clone())
+		}
+	}
+	return list
+}
+
+func (s *queueState) expired(item *Item, now time.Time) bool {
+	return now.Sub(item.ClaimedAt) > s.lease
+}
+
+// sorted returns the items sorted by CreatedAt, the ID breaks the tie
+func (s *queueState) sorted() []*Item {
+	list := make([]*Item, 0, len(s.items))
+	for _, item := range s.items {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
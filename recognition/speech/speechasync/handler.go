@@ -4,6 +4,7 @@ package speechasync
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"sync"
 
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
@@ -107,6 +108,16 @@ func (asyncHdler *AsyncHandler) SetTimeout(timeout int) {
 	asyncHdler.hdler.SetTimeout(timeout)
 }
 
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (asyncHdler *AsyncHandler) SetTransport(transport http.RoundTripper) {
+	asyncHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (asyncHdler *AsyncHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	asyncHdler.hdler.SetInterceptor(interceptor)
+}
+
 func (syncHdler *AsyncHandler) recycleDataObj(speechAsync *SpeechAsync) {
 	speechAsync.ClearData()
 	syncHdler.asyncPool.Put(speechAsync)
@@ -0,0 +1,154 @@
+// Package speechroute provide routing of speech to the sync or async recognition by its length
+package speechroute
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tupuaudio "github.com/tuputech/tupu-go-sdk/lib/audio"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupumediatype "github.com/tuputech/tupu-go-sdk/lib/mediatype"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechasync"
+	"github.com/tuputech/tupu-go-sdk/recognition/speech/speechsync"
+)
+
+const (
+	// RouteSync is the route of speechsync
+	RouteSync Route = "sync"
+	// RouteAsync is the route of speechasync
+	RouteAsync Route = "async"
+
+	// DefaultMaxSyncDuration is the default max play time of the speech sent to speechsync
+	DefaultMaxSyncDuration = time.Minute
+)
+
+// DefaultMaxSyncBytes is the default max size of the speech sent to speechsync
+var DefaultMaxSyncBytes = tupumediatype.SpeechSyncRule.MaxBytes
+
+// ErrNoURL is returned when the speech routed to speechasync has no URL and no uploader is set
+var ErrNoURL = errors.New("speech routed to async recognition needs a url")
+
+type (
+	// Route is the recognition API the speech is sent to
+	Route string
+
+	// Decision is the route of a speech and why it is taken
+	Decision struct {
+		Route Route
+		// Info is nil if the speech can't be probed
+		Info   *tupuaudio.Info
+		Reason string
+	}
+
+	// UploadFunc uploads the speech to a storage reachable by TUPU and returns its URL
+	UploadFunc func(name string, buf []byte) (url string, err error)
+
+	// Router sends a speech to speechsync or speechasync by its duration and size
+	Router struct {
+		syncHdler       *speechsync.SyncHandler
+		asyncHdler      *speechasync.AsyncHandler
+		maxSyncDuration time.Duration
+		maxSyncBytes    int64
+		upload          UploadFunc
+	}
+
+	// RouterOptFunc is a function to set the option of Router
+	RouterOptFunc func(*Router)
+)
+
+// WithMaxSyncDuration sets the max play time of the speech sent to speechsync
+func WithMaxSyncDuration(d time.Duration) RouterOptFunc {
+	return func(r *Router) {
+		if d > 0 {
+			r.maxSyncDuration = d
+		}
+	}
+}
+
+// WithMaxSyncBytes sets the max size of the speech sent to speechsync, it is the only
+// threshold for the speech which can't be probed
+func WithMaxSyncBytes(n int64) RouterOptFunc {
+	return func(r *Router) {
+		if n > 0 {
+			r.maxSyncBytes = n
+		}
+	}
+}
+
+// WithUploader sets the function to get the URL of the binary speech routed to speechasync
+func WithUploader(upload UploadFunc) RouterOptFunc {
+	return func(r *Router) {
+		r.upload = upload
+	}
+}
+
+// NewRouter is an initializer for a Router
+func NewRouter(syncHdler *speechsync.SyncHandler, asyncHdler *speechasync.AsyncHandler, opts ...RouterOptFunc) (*Router, error) {
+	if syncHdler == nil || asyncHdler == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	r := &Router{
+		syncHdler:       syncHdler,
+		asyncHdler:      asyncHdler,
+		maxSyncDuration: DefaultMaxSyncDuration,
+		maxSyncBytes:    DefaultMaxSyncBytes,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Decide returns the route of the speech, the speech with unknown length is routed to speechasync
+func (r *Router) Decide(buf []byte) *Decision {
+	if len(buf) == 0 {
+		return &Decision{Route: RouteAsync, Reason: "unknown length"}
+	}
+	if int64(len(buf)) > r.maxSyncBytes {
+		return &Decision{Route: RouteAsync, Reason: fmt.Sprintf("size %d > %d", len(buf), r.maxSyncBytes)}
+	}
+
+	info, err := tupuaudio.Probe(buf)
+	if err != nil {
+		// fall back to the size for the formats can't be probed, such as flv and wmv
+		return &Decision{Route: RouteSync, Reason: fmt.Sprintf("size %d <= %d, %v", len(buf), r.maxSyncBytes, err)}
+	}
+	if info.Duration > r.maxSyncDuration {
+		return &Decision{Route: RouteAsync, Info: info, Reason: fmt.Sprintf("duration %v > %v", info.Duration, r.maxSyncDuration)}
+	}
+	return &Decision{Route: RouteSync, Info: info, Reason: fmt.Sprintf("duration %v <= %v", info.Duration, r.maxSyncDuration)}
+}
+
+// Perform recognizes the speech by the route of Decide. buf is probed if not empty, url is required
+// by speechasync unless an uploader is set. tasks are only for speechsync and asyncOpts only for speechasync
+func (r *Router) Perform(secretID, name string, buf []byte, url string, tasks []string, asyncOpts ...speechasync.SPAsyncOptFunc) (decision *Decision, result string, statusCode int, err error) {
+
+	// step1. Invalid parameter check
+	if tupuerror.StringIsEmpty(secretID) || (len(buf) == 0 && tupuerror.StringIsEmpty(url)) {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+
+	// step2. pick the route
+	decision = r.Decide(buf)
+
+	// step3. transfer the recognition api
+	if decision.Route == RouteSync {
+		result, statusCode, err = r.syncHdler.PerformWithBinary(secretID, map[string][]byte{name: buf}, tasks...)
+		return
+	}
+	if tupuerror.StringIsEmpty(url) {
+		if r.upload == nil {
+			err = ErrNoURL
+			return
+		}
+		if url, err = r.upload(name, buf); err != nil {
+			return
+		}
+	}
+	result, statusCode, err = r.asyncHdler.Perform(secretID, url, asyncOpts...)
+	return
+}
@@ -3,10 +3,15 @@ package speechstream
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
 	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupujobstore "github.com/tuputech/tupu-go-sdk/lib/jobstore"
 )
 
 const (
@@ -14,12 +19,19 @@ const (
 	SpeechStreamURL       = "http://api.open.tuputech.com/v3/recognition/speech/stream/"
 	SpeechStreamCloseURL  = "http://api.open.tuputech.com/v3/recognition/speech/stream/close/"
 	SpeechStreamSearchURL = "http://api.open.tuputech.com/v3/recognition/speech/stream/search/"
+
+	closePath  = "close/"
+	searchPath = "search/"
 )
 
 // SpeechStreamHandler is a client-side helper to access TUPU speech recognition service
 type SpeechStreamHandler struct {
 	syncPool sync.Pool
 	hdler    *tupucontrol.Handler
+	rootURL  string
+	store    tupujobstore.Store
+	// onStoreError is called when a stream can't be saved to the job store
+	onStoreError func(error)
 }
 
 // NewASyncHandler is an initializer for a SpeechHandler
@@ -31,7 +43,7 @@ func NewSpeechStreamHandler(privateKeyPath string) (*SpeechStreamHandler, error)
 
 	var (
 		err         error
-		spstrmHdler = new(SpeechStreamHandler)
+		spstrmHdler = &SpeechStreamHandler{rootURL: SpeechStreamURL}
 	)
 
 	if spstrmHdler.hdler, err = tupucontrol.NewHandlerWithURL(privateKeyPath, SpeechStreamURL); err != nil {
@@ -45,8 +57,15 @@ func NewSpeechStreamHandler(privateKeyPath string) (*SpeechStreamHandler, error)
 	return spstrmHdler, nil
 }
 
-// SetServerURL provide set request server URL attribute
+// SetServerURL provide set request server URL attribute, the close and search apis are under the same URL
 func (spstrmHdler *SpeechStreamHandler) SetServerURL(url string) {
+	if tupuerror.StringIsEmpty(url) {
+		return
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	spstrmHdler.rootURL = url
 	spstrmHdler.hdler.SetServerURL(url)
 }
 
@@ -55,11 +74,73 @@ func (spstrmHdler *SpeechStreamHandler) recycleDataObj(speechStream *SpeechStrea
 	spstrmHdler.syncPool.Put(speechStream)
 }
 
+// SetJobStore provide saving the started streams to store, so they can be closed by CloseOrphanedSessions after restart
+func (spstrmHdler *SpeechStreamHandler) SetJobStore(store tupujobstore.Store) {
+	spstrmHdler.store = store
+}
+
+// SetStoreErrorFunc provide setting the function called when a stream can't be saved to the job store
+func (spstrmHdler *SpeechStreamHandler) SetStoreErrorFunc(onStoreError func(error)) {
+	spstrmHdler.onStoreError = onStoreError
+}
+
+// PruneSessions deletes the streams over more than maxAge ago from the job store, so the
+// log of a file store doesn't grow forever, the number of deleted streams is returned
+func (spstrmHdler *SpeechStreamHandler) PruneSessions(maxAge time.Duration) (int, error) {
+	if spstrmHdler.store == nil {
+		return 0, fmt.Errorf("no job store, %s", tupuerror.GetCallerFuncName())
+	}
+	return tupujobstore.Prune(spstrmHdler.store, tupujobstore.KindSpeechStream, maxAge)
+}
+
+// HandleCallback saves a verified callback result to the job store as the latest result of its stream,
+// callbacks of unknown streams are ignored
+func (spstrmHdler *SpeechStreamHandler) HandleCallback(result string) error {
+	var data struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal([]byte(result), &data); err != nil {
+		return fmt.Errorf("invalid speech stream callback: %v", err)
+	}
+	if spstrmHdler.store == nil || len(data.RequestID) == 0 {
+		return nil
+	}
+	spstrmHdler.persist(data.RequestID, false, func(rec *tupujobstore.Record) {
+		rec.Callbacks++
+		rec.LastResult = result
+	})
+	return nil
+}
+
+// NewCallbackReceiver creates a http.Handler which passes the callbacks to HandleCallback and then onResult,
+// onResult can be nil if the results are only saved to the job store
+func (spstrmHdler *SpeechStreamHandler) NewCallbackReceiver(onResult tupucallback.ResultFunc) (*tupucallback.Receiver, error) {
+	return tupucallback.NewReceiver(func(result string) error {
+		if err := spstrmHdler.HandleCallback(result); err != nil {
+			return err
+		}
+		if onResult != nil {
+			return onResult(result)
+		}
+		return nil
+	})
+}
+
 // SetTimeout provide properties to set request ttl
 func (spstrmHdler *SpeechStreamHandler) SetTimeout(timeout int) {
 	spstrmHdler.hdler.SetTimeout(timeout)
 }
 
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (spstrmHdler *SpeechStreamHandler) SetTransport(transport http.RoundTripper) {
+	spstrmHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (spstrmHdler *SpeechStreamHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	spstrmHdler.hdler.SetInterceptor(interceptor)
+}
+
 // Perform is the major method for initiating a recognition request
 func (spstrmHdler *SpeechStreamHandler) StartStreamRecognition(secretID, streamUrl, callbackUrl string, optFuncs ...StreamOptFunc) (result string, statusCode int, err error) {
 
@@ -76,7 +157,6 @@ func (spstrmHdler *SpeechStreamHandler) StartStreamRecognition(secretID, streamU
 		paramsStr     string
 	)
 
-	spstrmHdler.hdler.SetServerURL(SpeechStreamURL)
 	speechStream = spstrmHdler.syncPool.Get().(*SpeechStream)
 	defer spstrmHdler.recycleDataObj(speechStream)
 
@@ -97,7 +177,10 @@ func (spstrmHdler *SpeechStreamHandler) StartStreamRecognition(secretID, streamU
 		paramsStr += `,"tasks":` + string(taskStrSlice)
 	}
 	// step3. transfer general api
-	return spstrmHdler.hdler.RecognizeWithJSON(paramsStr, secretID)
+	if result, statusCode, err = spstrmHdler.hdler.RecognizeWithJSONAndURL(spstrmHdler.rootURL, paramsStr, secretID); err == nil && statusCode < 300 {
+		spstrmHdler.persistStarted(secretID, streamUrl, callbackUrl, result)
+	}
+	return
 }
 
 // CloseRecognitionTask can close your speech recognition task by requestId
@@ -107,9 +190,34 @@ func (spstrmHdler *SpeechStreamHandler) CloseRecognitionTask(secretID, requestId
 		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
 		return
 	}
-	spstrmHdler.SetServerURL(SpeechStreamCloseURL)
 	requestParams := `"speechStream":[{"requestId": "` + requestId + `"}]`
-	return spstrmHdler.hdler.RecognizeWithJSON(requestParams, secretID)
+	if result, statusCode, err = spstrmHdler.hdler.RecognizeWithJSONAndURL(spstrmHdler.rootURL+closePath, requestParams, secretID); err == nil && statusCode < 300 {
+		spstrmHdler.persistState(requestId, tupujobstore.StateCancelled)
+	}
+	return
+}
+
+// CloseOrphanedSessions closes the unfinished streams saved in the job store, it is used on startup
+// to stop the streams started before the process restarted, the requestIds of closed streams are returned
+func (spstrmHdler *SpeechStreamHandler) CloseOrphanedSessions() (closed []string, err error) {
+	if spstrmHdler.store == nil {
+		return nil, fmt.Errorf("no job store, %s", tupuerror.GetCallerFuncName())
+	}
+
+	var records []*tupujobstore.Record
+	if records, err = tupujobstore.Pending(spstrmHdler.store, tupujobstore.KindSpeechStream); err != nil {
+		return nil, err
+	}
+
+	for _, rec := range records {
+		// the error is kept and the rest streams are closed
+		if _, _, e := spstrmHdler.CloseRecognitionTask(rec.SecretID, rec.ID); e != nil {
+			err = e
+			continue
+		}
+		closed = append(closed, rec.ID)
+	}
+	return closed, err
 }
 
 // QueryStatus can query your video recognition result by requestId
@@ -119,8 +227,56 @@ func (spstrmHdler *SpeechStreamHandler) QueryStatus(secretID, requestId string)
 		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
 		return
 	}
-	spstrmHdler.SetServerURL(SpeechStreamSearchURL)
-
 	requestParams := `"requestId": "` + requestId + `"`
-	return spstrmHdler.hdler.RecognizeWithJSON(requestParams, secretID)
+	return spstrmHdler.hdler.RecognizeWithJSONAndURL(spstrmHdler.rootURL+searchPath, requestParams, secretID)
+}
+
+func (spstrmHdler *SpeechStreamHandler) persistStarted(secretID, streamUrl, callbackUrl, result string) {
+	if spstrmHdler.store == nil {
+		return
+	}
+	r, err := ParseStreamResult(result)
+	if err != nil || r.Code != 0 || len(r.RequestID()) == 0 {
+		return
+	}
+	spstrmHdler.persist(r.RequestID(), true, func(rec *tupujobstore.Record) {
+		rec.SecretID = secretID
+		rec.Source = streamUrl
+		rec.State = tupujobstore.StateRunning
+		rec.Extra = map[string]string{recordCallbackKey: callbackUrl}
+	})
+}
+
+// persistState saves the state of a stream, the latest callback is kept as the final result when it is over
+func (spstrmHdler *SpeechStreamHandler) persistState(requestId, state string) {
+	if spstrmHdler.store == nil {
+		return
+	}
+	spstrmHdler.persist(requestId, false, func(rec *tupujobstore.Record) {
+		rec.State = state
+		if rec.IsOver() && len(rec.Result) == 0 {
+			rec.Result = rec.LastResult
+		}
+	})
+}
+
+// persist updates the record of requestId, a missing record is only created if create is true
+func (spstrmHdler *SpeechStreamHandler) persist(requestId string, create bool, fn func(rec *tupujobstore.Record)) {
+	if !create {
+		if _, err := spstrmHdler.store.Get(tupujobstore.KindSpeechStream, requestId); err == tupujobstore.ErrNotFound {
+			return
+		} else if err != nil {
+			spstrmHdler.storeError(err)
+			return
+		}
+	}
+	if err := tupujobstore.Update(spstrmHdler.store, tupujobstore.KindSpeechStream, requestId, fn); err != nil {
+		spstrmHdler.storeError(err)
+	}
+}
+
+func (spstrmHdler *SpeechStreamHandler) storeError(err error) {
+	if spstrmHdler.onStoreError != nil {
+		spstrmHdler.onStoreError(err)
+	}
 }
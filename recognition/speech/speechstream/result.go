@@ -0,0 +1,45 @@
+package speechstream
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type (
+	// StreamResult is a wrapper for the result of speech stream apis
+	StreamResult struct {
+		Code      int            `json:"code"`
+		Message   string         `json:"message"`
+		Timestamp int64          `json:"timestamp"`
+		Nonce     string         `json:"nonce"`
+		Result    []StreamStatus `json:"result"`
+	}
+
+	// StreamStatus is the state of a speech stream in StreamResult
+	StreamStatus struct {
+		RequestID string `json:"requestId"`
+		URL       string `json:"url,omitempty"`
+		// Status is the recognition status of the stream
+		Status interface{} `json:"status,omitempty"`
+		// Code is the error code of the stream, 0 means success
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// ParseStreamResult is a helper to parse json string returned by speech stream apis
+func ParseStreamResult(s string) (*StreamResult, error) {
+	r := new(StreamResult)
+	if err := json.Unmarshal([]byte(s), r); err != nil {
+		return nil, fmt.Errorf("invalid speech stream result: %v", err)
+	}
+	return r, nil
+}
+
+// RequestID returns the requestId of the first stream in the result
+func (r *StreamResult) RequestID() string {
+	if len(r.Result) == 0 {
+		return ""
+	}
+	return r.Result[0].RequestID
+}
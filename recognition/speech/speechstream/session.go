@@ -0,0 +1,475 @@
+package speechstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupujobstore "github.com/tuputech/tupu-go-sdk/lib/jobstore"
+)
+
+const (
+	// DefaultCheckInterval is the default interval to query the status of a session
+	DefaultCheckInterval = 30 * time.Second
+	// DefaultMaxReconnects is the default number of reconnections of a session
+	DefaultMaxReconnects = 3
+	// DefaultMaxCheckFailures is the number of continuous failed queries which causes a reconnection
+	DefaultMaxCheckFailures = 3
+
+	// recordCallbackKey is the key of callback url in jobstore.Record.Extra
+	recordCallbackKey = "callback"
+)
+
+var (
+	// ErrMaxDurationReached is the error of a session closed because it runs longer than the max duration
+	ErrMaxDurationReached = errors.New("speech stream session reached max duration")
+	// ErrTooManyReconnects is the error of a session which still fails after max reconnections
+	ErrTooManyReconnects = errors.New("speech stream session reconnected too many times")
+	// ErrSessionClosed is the error of a session closed by Close
+	ErrSessionClosed = errors.New("speech stream session is closed")
+	// ErrStreamEnded is the error of a session whose stream is finished normally on TUPU
+	ErrStreamEnded = errors.New("speech stream is ended")
+
+	// DefaultEndedStatus is the status of a stream which means the recognition is finished normally
+	DefaultEndedStatus = []string{"closed", "close", "end", "ended", "stop", "stopped"}
+	// DefaultFailedStatus is the status of a stream which means the recognition is broken and should be restarted
+	DefaultFailedStatus = []string{"error", "failed"}
+)
+
+type (
+	// SessionManager starts speech stream recognitions as Sessions and keeps the registry of active sessions
+	SessionManager struct {
+		spstrmHdler      *SpeechStreamHandler
+		secretID         string
+		checkInterval    time.Duration
+		maxDuration      time.Duration
+		maxReconnects    int
+		maxCheckFailures int
+		isAlive          func(*StreamStatus) bool
+
+		mu       sync.RWMutex
+		sessions map[string]*Session
+	}
+
+	// SessionOptFunc is the optional setting of SessionManager
+	SessionOptFunc func(*SessionManager)
+
+	// Session is a running speech stream recognition, it is closed automatically
+	// when the context is cancelled or the max duration elapses
+	Session struct {
+		// StreamURL is the url of the speech stream
+		StreamURL string
+		// CallbackURL is the url to receive the recognition result
+		CallbackURL string
+		// StartedAt is the time of starting the session
+		StartedAt time.Time
+
+		manager  *SessionManager
+		optFuncs []StreamOptFunc
+		cancel   context.CancelFunc
+		done     chan struct{}
+
+		mu         sync.Mutex
+		requestID  string
+		status     *StreamStatus
+		reconnects int
+		err        error
+		closeErr   error
+	}
+)
+
+// NewSessionManager is an initializer for a SessionManager, all sessions are started with the secretID
+func NewSessionManager(spstrmHdler *SpeechStreamHandler, secretID string, optFuncs ...SessionOptFunc) (*SessionManager, error) {
+	if tupuerror.PtrIsNil(spstrmHdler) || tupuerror.StringIsEmpty(secretID) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	m := &SessionManager{
+		spstrmHdler:      spstrmHdler,
+		secretID:         secretID,
+		checkInterval:    DefaultCheckInterval,
+		maxReconnects:    DefaultMaxReconnects,
+		maxCheckFailures: DefaultMaxCheckFailures,
+		isAlive:          defaultIsAlive,
+		sessions:         make(map[string]*Session),
+	}
+	for _, setConf := range optFuncs {
+		setConf(m)
+	}
+	return m, nil
+}
+
+// WithCheckInterval sets the interval to query the status of sessions
+func WithCheckInterval(interval time.Duration) SessionOptFunc {
+	return func(m *SessionManager) {
+		if interval > 0 {
+			m.checkInterval = interval
+		}
+	}
+}
+
+// WithMaxDuration sets the longest running time of a session, 0 means no limit
+func WithMaxDuration(maxDuration time.Duration) SessionOptFunc {
+	return func(m *SessionManager) {
+		m.maxDuration = maxDuration
+	}
+}
+
+// WithMaxReconnects sets the number of reconnections before a session gives up
+func WithMaxReconnects(maxReconnects int) SessionOptFunc {
+	return func(m *SessionManager) {
+		m.maxReconnects = maxReconnects
+	}
+}
+
+// WithMaxCheckFailures sets the number of continuous failed queries which causes a reconnection
+func WithMaxCheckFailures(maxCheckFailures int) SessionOptFunc {
+	return func(m *SessionManager) {
+		if maxCheckFailures > 0 {
+			m.maxCheckFailures = maxCheckFailures
+		}
+	}
+}
+
+// WithAliveFunc replaces the function to decide whether a stream is still recognized by its status,
+// a stopped stream is restarted if its status is failed, otherwise the session is finished with ErrStreamEnded
+func WithAliveFunc(isAlive func(*StreamStatus) bool) SessionOptFunc {
+	return func(m *SessionManager) {
+		if isAlive != nil {
+			m.isAlive = isAlive
+		}
+	}
+}
+
+// Start starts the recognition of streamURL and returns the Session, the session is closed when ctx is done
+func (m *SessionManager) Start(ctx context.Context, streamURL, callbackURL string, optFuncs ...StreamOptFunc) (*Session, error) {
+	requestID, err := m.start(streamURL, callbackURL, optFuncs)
+	if err != nil {
+		return nil, err
+	}
+	return m.track(ctx, requestID, streamURL, callbackURL, optFuncs), nil
+}
+
+// Recover resumes the sessions of the secretID saved in the job store of the SpeechStreamHandler,
+// the optional params of StartStreamRecognition are not saved, so they are not used when reconnecting
+func (m *SessionManager) Recover(ctx context.Context) ([]*Session, error) {
+	if m.spstrmHdler.store == nil {
+		return nil, fmt.Errorf("no job store, %s", tupuerror.GetCallerFuncName())
+	}
+
+	records, err := tupujobstore.Pending(m.spstrmHdler.store, tupujobstore.KindSpeechStream)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(records))
+	for _, rec := range records {
+		if rec.SecretID != m.secretID {
+			continue
+		}
+		if _, ok := m.Session(rec.ID); ok {
+			continue
+		}
+		sessions = append(sessions, m.track(ctx, rec.ID, rec.Source, rec.Extra[recordCallbackKey], nil))
+	}
+	return sessions, nil
+}
+
+// Session returns the active session of requestID
+func (m *SessionManager) Session(requestID string) (*Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[requestID]
+	return s, ok
+}
+
+// Sessions returns all active sessions
+func (m *SessionManager) Sessions() []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Len returns the number of active sessions
+func (m *SessionManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.sessions)
+}
+
+// CloseAll closes all active sessions, the last error is returned
+func (m *SessionManager) CloseAll() (err error) {
+	for _, s := range m.Sessions() {
+		if e := s.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (m *SessionManager) start(streamURL, callbackURL string, optFuncs []StreamOptFunc) (string, error) {
+	result, statusCode, err := m.spstrmHdler.StartStreamRecognition(m.secretID, streamURL, callbackURL, optFuncs...)
+	r, err := checkStreamResult(result, statusCode, err)
+	if err != nil {
+		return "", err
+	}
+	if len(r.RequestID()) == 0 {
+		return "", fmt.Errorf("no requestId in result: %s", result)
+	}
+	return r.RequestID(), nil
+}
+
+func (m *SessionManager) track(ctx context.Context, requestID, streamURL, callbackURL string, optFuncs []StreamOptFunc) *Session {
+	s := &Session{
+		StreamURL:   streamURL,
+		CallbackURL: callbackURL,
+		StartedAt:   time.Now(),
+		manager:     m,
+		optFuncs:    optFuncs,
+		done:        make(chan struct{}),
+		requestID:   requestID,
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	m.mu.Lock()
+	m.sessions[requestID] = s
+	m.mu.Unlock()
+
+	go s.run(ctx)
+	return s
+}
+
+func (m *SessionManager) rekey(s *Session, oldID, newID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[oldID] == s {
+		delete(m.sessions, oldID)
+	}
+	m.sessions[newID] = s
+}
+
+func (m *SessionManager) remove(s *Session) {
+	requestID := s.RequestID()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[requestID] == s {
+		delete(m.sessions, requestID)
+	}
+}
+
+// RequestID returns the current requestId of the session, it changes after reconnection
+func (s *Session) RequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestID
+}
+
+// Status returns the latest status queried by the session
+func (s *Session) Status() *StreamStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Reconnects returns the number of reconnections of the session
+func (s *Session) Reconnects() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reconnects
+}
+
+// Done returns a channel which is closed when the session is over
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason why the session is over, it is nil while the session is active
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close closes the recognition task and waits for the session to be over,
+// the error of closing the task on TUPU is returned if the session is not over before
+func (s *Session) Close() error {
+	s.setErr(ErrSessionClosed)
+	s.cancel()
+	<-s.done
+	if err := s.Err(); err != ErrSessionClosed && err != ErrStreamEnded {
+		return err
+	}
+	return s.CloseErr()
+}
+
+// CloseErr returns the error of closing the recognition task on TUPU when the session is over
+func (s *Session) CloseErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeErr
+}
+
+func (s *Session) run(ctx context.Context) {
+	var (
+		m        = s.manager
+		failures = 0
+		deadline <-chan time.Time
+		// spread the queries of sessions started at the same time
+		ticker = time.NewTimer(time.Duration(rand.Int63n(int64(m.checkInterval))) + m.checkInterval/2)
+	)
+	defer ticker.Stop()
+	defer close(s.done)
+	defer m.remove(s)
+
+	if m.maxDuration > 0 {
+		timer := time.NewTimer(m.maxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.setErr(ctx.Err())
+			s.setCloseErr(s.closeTask())
+			return
+		case <-deadline:
+			s.setErr(ErrMaxDurationReached)
+			s.setCloseErr(s.closeTask())
+			return
+		case <-ticker.C:
+		}
+
+		status, err := s.check()
+		if err == nil && m.isAlive(status) {
+			failures = 0
+		} else if err == nil && !isFailed(status) {
+			// the stream is finished normally, there is nothing to restart
+			s.setErr(ErrStreamEnded)
+			m.spstrmHdler.persistState(s.RequestID(), tupujobstore.StateFinished)
+			return
+		} else if failures++; err == nil || failures >= m.maxCheckFailures {
+			// the stream is broken on TUPU or can't be queried, restart it
+			if err = s.reconnect(); err != nil {
+				s.setErr(err)
+				return
+			}
+			failures = 0
+		}
+		ticker.Reset(m.checkInterval)
+	}
+}
+
+func (s *Session) check() (*StreamStatus, error) {
+	requestID := s.RequestID()
+	r, err := checkStreamResult(s.manager.spstrmHdler.QueryStatus(s.manager.secretID, requestID))
+	if err != nil {
+		return nil, err
+	}
+
+	status := new(StreamStatus)
+	for i := range r.Result {
+		if r.Result[i].RequestID == requestID || len(r.Result) == 1 {
+			*status = r.Result[i]
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	return status, nil
+}
+
+func (s *Session) reconnect() error {
+	m := s.manager
+	s.mu.Lock()
+	if s.reconnects >= m.maxReconnects {
+		s.mu.Unlock()
+		if err := s.closeTask(); err != nil {
+			return fmt.Errorf("%w, close task: %v", ErrTooManyReconnects, err)
+		}
+		return ErrTooManyReconnects
+	}
+	s.reconnects++
+	s.mu.Unlock()
+
+	// the old task may be still running on TUPU, the error is ignored
+	// because a broken task is usually closed by TUPU already
+	_ = s.closeTask()
+	requestID, err := m.start(s.StreamURL, s.CallbackURL, s.optFuncs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	oldID := s.requestID
+	s.requestID = requestID
+	s.mu.Unlock()
+	m.rekey(s, oldID, requestID)
+	return nil
+}
+
+func (s *Session) closeTask() error {
+	_, err := checkStreamResult(s.manager.spstrmHdler.CloseRecognitionTask(s.manager.secretID, s.RequestID()))
+	return err
+}
+
+func (s *Session) setCloseErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeErr = err
+}
+
+func (s *Session) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func defaultIsAlive(status *StreamStatus) bool {
+	if status == nil || isFailed(status) {
+		return false
+	}
+	return !hasStatus(status, DefaultEndedStatus)
+}
+
+// isFailed reports whether a stopped stream is broken, rather than finished normally
+func isFailed(status *StreamStatus) bool {
+	return status == nil || status.Code != 0 || hasStatus(status, DefaultFailedStatus)
+}
+
+func hasStatus(status *StreamStatus, states []string) bool {
+	state := fmt.Sprint(status.Status)
+	for _, s := range states {
+		if strings.EqualFold(state, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkStreamResult(result string, statusCode int, err error) (*StreamResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseStreamResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode > 299 || r.Code != 0 {
+		return r, fmt.Errorf("status code: %d, code: %d, message: %s", statusCode, r.Code, r.Message)
+	}
+	return r, nil
+}
@@ -0,0 +1,258 @@
+package speechsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tupuaudio "github.com/tuputech/tupu-go-sdk/lib/audio"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+// DefaultConcurrency is the default number of segments recognized at the same time
+const DefaultConcurrency = 4
+
+type (
+	// SegmentResult is the recognition result of a segment of the long audio
+	SegmentResult struct {
+		Index      int
+		Offset     time.Duration
+		Duration   time.Duration
+		Result     string
+		StatusCode int
+		Err        error
+	}
+
+	// TimelineEntry is a recognized part of the long audio, Start and End are absolute offsets
+	TimelineEntry struct {
+		TaskID  string
+		Segment int
+		Start   time.Duration
+		End     time.Duration
+		Label   int
+		Review  bool
+		// Raw is the original detail, or the file result if there is no detail
+		Raw map[string]interface{}
+	}
+
+	// Timeline is the stitched result of all segments of the long audio
+	Timeline struct {
+		Segments []*SegmentResult
+		// Entries is sorted by Start
+		Entries []*TimelineEntry
+	}
+
+	// LongAudioOptFunc is a function to set the option of PerformLongAudio
+	LongAudioOptFunc func(*longAudioConfig)
+
+	longAudioConfig struct {
+		splitter    *tupuaudio.Splitter
+		concurrency int
+		timeUnit    time.Duration
+	}
+)
+
+// WithSplitter sets the splitter of the long audio, fixed-length segments of
+// tupuaudio.DefaultSegmentDuration by default
+func WithSplitter(splitter *tupuaudio.Splitter) LongAudioOptFunc {
+	return func(c *longAudioConfig) {
+		c.splitter = splitter
+	}
+}
+
+// WithConcurrency sets the number of segments recognized at the same time
+func WithConcurrency(n int) LongAudioOptFunc {
+	return func(c *longAudioConfig) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithTimeUnit sets the unit of startTime and endTime in the segment results, time.Second by default
+func WithTimeUnit(unit time.Duration) LongAudioOptFunc {
+	return func(c *longAudioConfig) {
+		if unit > 0 {
+			c.timeUnit = unit
+		}
+	}
+}
+
+// Failed returns the segments failed to be recognized
+func (tl *Timeline) Failed() []*SegmentResult {
+	var failed []*SegmentResult
+	for _, seg := range tl.Segments {
+		if seg.Err != nil {
+			failed = append(failed, seg)
+		}
+	}
+	return failed
+}
+
+// PerformLongAudio splits a WAV file into segments, recognizes them concurrently and stitches
+// the results into one timeline. Raw PCM can be wrapped by tupuaudio.EncodeWAV. The timeline
+// is returned with the first error if some segments failed, a non-zero result code fails the segment
+func (syncHdler *SyncHandler) PerformLongAudio(ctx context.Context, secretID, name string, wav []byte, tasks []string, opts ...LongAudioOptFunc) (*Timeline, error) {
+	if tupuerror.StringIsEmpty(secretID) || len(wav) == 0 {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	conf := &longAudioConfig{concurrency: DefaultConcurrency, timeUnit: time.Second}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if conf.splitter == nil {
+		conf.splitter = tupuaudio.NewSplitter()
+	}
+
+	// step1. split the audio
+	segments, err := conf.splitter.SplitWAV(wav)
+	if err != nil {
+		return nil, err
+	}
+
+	// step2. recognize the segments concurrently
+	var (
+		tl    = &Timeline{Segments: make([]*SegmentResult, len(segments))}
+		base  = strings.TrimSuffix(name, filepath.Ext(name))
+		slots = make(chan struct{}, conf.concurrency)
+		wg    sync.WaitGroup
+	)
+	for i, seg := range segments {
+		tl.Segments[i] = &SegmentResult{Index: seg.Index, Offset: seg.Offset, Duration: seg.Duration}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			tl.Segments[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(sr *SegmentResult, seg tupuaudio.Segment) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				sr.Err = err
+				return
+			}
+			fileName := fmt.Sprintf("%s#%d.wav", base, seg.Index)
+			sr.Result, sr.StatusCode, sr.Err = syncHdler.PerformWithBinary(secretID, map[string][]byte{fileName: seg.Data}, tasks...)
+			if sr.Err == nil && sr.StatusCode > 299 {
+				sr.Err = fmt.Errorf("segment %d: status code: %d", seg.Index, sr.StatusCode)
+			}
+			if sr.Err == nil {
+				if r := tupumodel.ParseResult(sr.Result); r == nil {
+					sr.Err = fmt.Errorf("segment %d: invalid result", seg.Index)
+				} else if r.Code != 0 {
+					sr.Err = fmt.Errorf("segment %d: code: %d, message: %s", seg.Index, r.Code, r.Message)
+				}
+			}
+		}(tl.Segments[i], seg)
+	}
+	wg.Wait()
+
+	// step3. stitch the segment results
+	var firstErr error
+	for _, sr := range tl.Segments {
+		if sr.Err != nil {
+			if firstErr == nil {
+				firstErr = sr.Err
+			}
+			continue
+		}
+		tl.Entries = append(tl.Entries, stitchSegment(sr, conf.timeUnit)...)
+	}
+	sort.SliceStable(tl.Entries, func(i, j int) bool {
+		return tl.Entries[i].Start < tl.Entries[j].Start
+	})
+	return tl, firstErr
+}
+
+// stitchSegment returns the entries of a segment result, the details with startTime and
+// endTime are shifted by the offset of the segment, a file result without details covers
+// the whole segment
+func stitchSegment(sr *SegmentResult, unit time.Duration) []*TimelineEntry {
+	var data map[string]interface{}
+	if json.Unmarshal([]byte(sr.Result), &data) != nil {
+		return nil
+	}
+
+	var entries []*TimelineEntry
+	for taskID, val := range data {
+		task, ok := val.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, file := range taskFiles(task) {
+			details, _ := file["details"].([]interface{})
+			if len(details) == 0 {
+				entries = append(entries, newEntry(taskID, sr, file, sr.Offset, sr.Offset+sr.Duration))
+				continue
+			}
+			for _, d := range details {
+				detail, ok := d.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				start, end := sr.Offset, sr.Offset+sr.Duration
+				if v, ok := detail["startTime"].(float64); ok {
+					start = sr.Offset + time.Duration(v*float64(unit))
+				}
+				if v, ok := detail["endTime"].(float64); ok {
+					end = sr.Offset + time.Duration(v*float64(unit))
+				}
+				entry := newEntry(taskID, sr, detail, start, end)
+				// a detail without label takes the label of the file
+				if _, ok := detail["label"]; !ok {
+					entry.Label, _ = labelOf(file)
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// taskFiles returns the results of files in a task, the list key differs between APIs
+func taskFiles(task map[string]interface{}) []map[string]interface{} {
+	var files []map[string]interface{}
+	for _, val := range task {
+		list, ok := val.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, item := range list {
+			if file, ok := item.(map[string]interface{}); ok {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+func newEntry(taskID string, sr *SegmentResult, raw map[string]interface{}, start, end time.Duration) *TimelineEntry {
+	label, review := labelOf(raw)
+	return &TimelineEntry{
+		TaskID:  taskID,
+		Segment: sr.Index,
+		Start:   start,
+		End:     end,
+		Label:   label,
+		Review:  review,
+		Raw:     raw,
+	}
+}
+
+func labelOf(raw map[string]interface{}) (int, bool) {
+	label, _ := raw["label"].(float64)
+	review, _ := raw["review"].(bool)
+	return int(label), review
+}
@@ -0,0 +1,169 @@
+package textasync
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
+	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+)
+
+const (
+	// TextAsyncAPIURL is default
+	TextAsyncAPIURL = "http://api.open.tuputech.com/v3/recognition/text/async/"
+	// TextAsyncResultURL is the address to query the result by requestId
+	TextAsyncResultURL = TextAsyncAPIURL + resultPath
+
+	resultPath = "result/"
+)
+
+// AsyncHandler is a client-side helper to access TUPU async text recognition service
+type AsyncHandler struct {
+	hdler   *tupucontrol.Handler
+	rootURL string
+}
+
+// NewTextAsyncHandler is an initializer for a AsyncHandler.
+func NewTextAsyncHandler(privateKeyPath string) (*AsyncHandler, error) {
+
+	// step1. Invalid parameter check
+	if tupuerror.StringIsEmpty(privateKeyPath) {
+		return nil, fmt.Errorf("[Params ERROR]: function name is %s", tupuerror.GetCallerFuncName())
+	}
+
+	var (
+		err        error
+		asyncHdler = &AsyncHandler{rootURL: TextAsyncAPIURL}
+	)
+
+	// create TUPU general Handler
+	if asyncHdler.hdler, err = tupucontrol.NewHandlerWithURL(privateKeyPath, TextAsyncAPIURL); err != nil {
+		return nil, err
+	}
+
+	return asyncHdler, nil
+}
+
+// SetServerURL provide set request server URL attribute, the result api is under the same URL
+func (asyncHdler *AsyncHandler) SetServerURL(url string) {
+	if tupuerror.StringIsEmpty(url) {
+		return
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	asyncHdler.rootURL = url
+	asyncHdler.hdler.SetServerURL(url)
+}
+
+// SetTimeout provide properties to set request ttl
+func (asyncHdler *AsyncHandler) SetTimeout(timeout int) {
+	asyncHdler.hdler.SetTimeout(timeout)
+}
+
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (asyncHdler *AsyncHandler) SetTransport(transport http.RoundTripper) {
+	asyncHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (asyncHdler *AsyncHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	asyncHdler.hdler.SetInterceptor(interceptor)
+}
+
+// Perform is the major method for initiating a text async recognition request,
+// the result is posted to callbackURL and can be queried by the requestId in the response
+func (asyncHdler *AsyncHandler) Perform(secretID, callbackURL string, texts []TextItem, optFuncs ...AsyncOptFunc) (result string, statusCode int, err error) {
+
+	// step1. Invalid parameter check
+	if tupuerror.StringIsEmpty(secretID, callbackURL) || len(texts) == 0 {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+
+	var (
+		textAsync = &TextAsync{
+			Text:        texts,
+			CallbackURL: callbackURL,
+		}
+		requestParams []byte
+	)
+	for _, setConf := range optFuncs {
+		setConf(textAsync)
+	}
+
+	// step2. serialize to JSON string
+	if requestParams, err = json.Marshal(textAsync); err != nil {
+		statusCode = 400
+		return
+	}
+
+	// step3. transfer general api
+	return asyncHdler.hdler.RecognizeWithJSONAndURL(asyncHdler.rootURL, string(requestParams[1:len(requestParams)-1]), secretID)
+}
+
+// PerformInBatches splits texts into requests of at most batchSize texts, the requestIds of
+// the accepted requests are returned, and the error of the first failed request stops the rest
+func (asyncHdler *AsyncHandler) PerformInBatches(secretID, callbackURL string, texts []TextItem, batchSize int, optFuncs ...AsyncOptFunc) (requestIDs []string, err error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		var r *AsyncResult
+		if r, err = checkAsyncResult(asyncHdler.Perform(secretID, callbackURL, texts[start:end], optFuncs...)); err != nil {
+			return requestIDs, fmt.Errorf("texts [%d, %d): %v", start, end, err)
+		}
+		requestIDs = append(requestIDs, r.RequestID)
+	}
+	return requestIDs, nil
+}
+
+// QueryResult can query your text recognition result by requestId
+func (asyncHdler *AsyncHandler) QueryResult(secretID, requestID string) (result string, statusCode int, err error) {
+	if tupuerror.StringIsEmpty(secretID, requestID) {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+
+	requestParams := `"requestId": "` + requestID + `"`
+	return asyncHdler.hdler.RecognizeWithJSONAndURL(asyncHdler.rootURL+resultPath, requestParams, secretID)
+}
+
+// NewCallbackReceiver creates a http.Handler to receive the callback of text async recognition
+func NewCallbackReceiver(onResult func(*AsyncResult) error) (*tupucallback.Receiver, error) {
+	if onResult == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	return tupucallback.NewReceiver(func(result string) error {
+		r, err := ParseAsyncResult(result)
+		if err != nil {
+			return err
+		}
+		return onResult(r)
+	})
+}
+
+func checkAsyncResult(result string, statusCode int, err error) (*AsyncResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseAsyncResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode > 299 || r.Code != 0 {
+		return r, fmt.Errorf("status code: %d, code: %d, message: %s", statusCode, r.Code, r.Message)
+	}
+	return r, nil
+}
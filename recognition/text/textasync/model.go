@@ -0,0 +1,51 @@
+// Package textasync provide interface of TUPU text async recognition
+package textasync
+
+type (
+	// TextItem is a text to recognize
+	TextItem struct {
+		Content   string `json:"content"`
+		ContentID string `json:"contentId,omitempty"`
+		UserID    string `json:"userId,omitempty"`
+		ForumID   string `json:"forumId,omitempty"`
+	}
+
+	// TextAsync is the params of a text async recognition request
+	TextAsync struct {
+		Text []TextItem `json:"text"`
+		// CallbackURL represents the address of the callback result, can't be empty
+		CallbackURL string `json:"callbackUrl"`
+		// CallbackRule represents the Rule of the callback, empty is using default rule, `all` is callback all result
+		CallbackRule string `json:"callbackRule,omitempty"`
+		// Tasks is the task ids to recognize, empty is using the tasks of the secretId
+		Tasks      []string               `json:"tasks,omitempty"`
+		CustomInfo map[string]interface{} `json:"customInfo,omitempty"`
+	}
+
+	// AsyncOptFunc is the optional setting of TextAsync
+	AsyncOptFunc func(*TextAsync)
+)
+
+const (
+	CallbackRuleALL = "all"
+	// DefaultBatchSize is the number of texts in one request of PerformInBatches
+	DefaultBatchSize = 100
+)
+
+func WithCallbackRule(callbackRule string) AsyncOptFunc {
+	return func(ta *TextAsync) {
+		ta.CallbackRule = callbackRule
+	}
+}
+
+func WithTask(tasks ...string) AsyncOptFunc {
+	return func(ta *TextAsync) {
+		ta.Tasks = tasks
+	}
+}
+
+func WithCustomInfo(customInfo map[string]interface{}) AsyncOptFunc {
+	return func(ta *TextAsync) {
+		ta.CustomInfo = customInfo
+	}
+}
@@ -0,0 +1,51 @@
+package textasync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+// AsyncResult is a wrapper for text async result parsed from response or callback
+type AsyncResult struct {
+	Code       int
+	Message    string
+	Timestamp  int64
+	Nonce      string
+	RequestID  string
+	CustomInfo map[string]interface{}
+	// Tasks is the recognition result of every task, key is the task id
+	Tasks map[string]interface{}
+	// Others is the rest fields of the result
+	Others map[string]interface{}
+	// Raw is the original json string
+	Raw string
+}
+
+// ParseAsyncResult is a helper to parse json string and create a AsyncResult struct
+func ParseAsyncResult(s string) (*AsyncResult, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil, fmt.Errorf("invalid text async result: %v", err)
+	}
+
+	r := &AsyncResult{Raw: s}
+	common, err := tupumodel.ParseResultData(data, func(key string, val interface{}) bool {
+		switch key {
+		case "requestId":
+			r.RequestID, _ = val.(string)
+		case "customInfo":
+			r.CustomInfo, _ = val.(map[string]interface{})
+		default:
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid text async result: %v", err)
+	}
+	r.Code, r.Message, r.Timestamp, r.Nonce = common.Code, common.Message, common.Timestamp, common.Nonce
+	r.Tasks, r.Others = common.Tasks, common.Others
+	return r, nil
+}
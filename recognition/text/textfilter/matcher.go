@@ -0,0 +1,100 @@
+package textfilter
+
+type (
+	// Matcher finds all patterns in a text at once with the Aho-Corasick automaton
+	Matcher struct {
+		nodes    []acNode
+		patterns [][]rune
+	}
+
+	// MatchPos is a pattern found in the text, Start and End are rune offsets in the text
+	MatchPos struct {
+		Pattern int
+		Start   int
+		End     int
+	}
+
+	acNode struct {
+		next    map[rune]int
+		fail    int
+		outputs []int
+	}
+)
+
+// NewMatcher builds a Matcher of patterns, the index of a pattern is reported in MatchPos
+func NewMatcher(patterns [][]rune) *Matcher {
+	m := &Matcher{
+		nodes:    []acNode{{next: make(map[rune]int)}},
+		patterns: patterns,
+	}
+
+	// step1. build the trie
+	for i, pattern := range patterns {
+		if len(pattern) == 0 {
+			continue
+		}
+		cur := 0
+		for _, r := range pattern {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				nxt = len(m.nodes)
+				m.nodes = append(m.nodes, acNode{next: make(map[rune]int)})
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].outputs = append(m.nodes[cur].outputs, i)
+	}
+
+	// step2. build the fail links by BFS
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for fail > 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if nxt, ok := m.nodes[fail].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			m.nodes[child].outputs = append(m.nodes[child].outputs, m.nodes[m.nodes[child].fail].outputs...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// FindAll returns all occurrences of the patterns in text, overlapped occurrences are included
+func (m *Matcher) FindAll(text []rune) []MatchPos {
+	var (
+		matches []MatchPos
+		cur     = 0
+	)
+	for i, r := range text {
+		for cur > 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		if nxt, ok := m.nodes[cur].next[r]; ok {
+			cur = nxt
+		}
+		for _, p := range m.nodes[cur].outputs {
+			matches = append(matches, MatchPos{
+				Pattern: p,
+				Start:   i + 1 - len(m.patterns[p]),
+				End:     i + 1,
+			})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,46 @@
+// Package textfilter provide local keyword filter of text before TUPU text recognition
+package textfilter
+
+import (
+	"unicode"
+)
+
+// Normalize folds content for matching, full-width characters are converted to half-width,
+// letters to lower case and traditional chinese to simplified chinese, and the spaces,
+// punctuation and symbols inserted between characters are dropped. The returned index maps
+// every rune of the folded text to its rune offset in content
+func Normalize(content string) (folded []rune, index []int) {
+	folded = make([]rune, 0, len(content))
+	index = make([]int, 0, len(content))
+
+	offset := 0
+	for _, r := range content {
+		if fr, ok := FoldRune(r); ok {
+			folded = append(folded, fr)
+			index = append(index, offset)
+		}
+		offset++
+	}
+	return folded, index
+}
+
+// FoldRune returns the folded form of r, false means r is ignored when matching
+func FoldRune(r rune) (rune, bool) {
+	switch {
+	case r == 0x3000:
+		// ideographic space
+		return r, false
+	case r >= 0xFF01 && r <= 0xFF5E:
+		// full-width ASCII variants
+		r -= 0xFEE0
+	}
+
+	if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) ||
+		unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+		return r, false
+	}
+	if s, ok := traditionalToSimplified[r]; ok {
+		return s, true
+	}
+	return unicode.ToLower(r), true
+}
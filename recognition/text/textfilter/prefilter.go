@@ -0,0 +1,114 @@
+package textfilter
+
+import (
+	"errors"
+	"sort"
+)
+
+const (
+	// ActionBlock means a text hitting the list is judged locally without calling TUPU
+	ActionBlock = "block"
+	// ActionAnnotate means a text hitting the list is still sent to TUPU with the hits attached
+	ActionAnnotate = "annotate"
+
+	// LocalTaskID is the task id of the verdict judged by Prefilter
+	LocalTaskID = "local"
+)
+
+// ErrNoKeywords is returned when a Prefilter is created without any keyword
+var ErrNoKeywords = errors.New("no valid keyword")
+
+type (
+	// KeywordList is a list of keywords with the same label and action
+	KeywordList struct {
+		Name     string
+		Label    int
+		Action   string
+		Keywords []string
+	}
+
+	// Hit is a keyword found in the text, Start and End are rune offsets in the original text
+	Hit struct {
+		List    string
+		Label   int
+		Action  string
+		Keyword string
+		Start   int
+		End     int
+	}
+
+	// Verdict is the result of Prefilter.Check
+	Verdict struct {
+		// Blocked is true if any hit has ActionBlock
+		Blocked bool
+		Hits    []Hit
+	}
+
+	// Prefilter matches the keyword lists in a text, the text and keywords are normalized
+	// by Normalize, so full-width, traditional chinese and inserted punctuation are matched too
+	Prefilter struct {
+		matcher *Matcher
+		entries []prefilterEntry
+	}
+
+	prefilterEntry struct {
+		list    *KeywordList
+		keyword string
+	}
+)
+
+// NewPrefilter is an initializer for a Prefilter, a list without Action is treated as ActionAnnotate
+func NewPrefilter(lists ...KeywordList) (*Prefilter, error) {
+	var (
+		p        = new(Prefilter)
+		patterns [][]rune
+	)
+	// the lists are referenced by hits, keep a copy of them
+	lists = append([]KeywordList(nil), lists...)
+	for i := range lists {
+		list := &lists[i]
+		if len(list.Action) == 0 {
+			list.Action = ActionAnnotate
+		}
+		for _, keyword := range list.Keywords {
+			folded, _ := Normalize(keyword)
+			if len(folded) == 0 {
+				continue
+			}
+			patterns = append(patterns, folded)
+			p.entries = append(p.entries, prefilterEntry{list: list, keyword: keyword})
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, ErrNoKeywords
+	}
+	p.matcher = NewMatcher(patterns)
+	return p, nil
+}
+
+// Check finds the keywords in content
+func (p *Prefilter) Check(content string) *Verdict {
+	var (
+		verdict       = new(Verdict)
+		folded, index = Normalize(content)
+	)
+
+	for _, pos := range p.matcher.FindAll(folded) {
+		entry := p.entries[pos.Pattern]
+		verdict.Hits = append(verdict.Hits, Hit{
+			List:    entry.list.Name,
+			Label:   entry.list.Label,
+			Action:  entry.list.Action,
+			Keyword: entry.keyword,
+			Start:   index[pos.Start],
+			End:     index[pos.End-1] + 1,
+		})
+		if entry.list.Action == ActionBlock {
+			verdict.Blocked = true
+		}
+	}
+	sort.SliceStable(verdict.Hits, func(i, j int) bool {
+		return verdict.Hits[i].Start < verdict.Hits[j].Start
+	})
+	return verdict
+}
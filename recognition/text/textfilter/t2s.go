@@ -0,0 +1,48 @@
+package textfilter
+
+// traditionalChars and simplifiedChars are the common traditional chinese characters
+// and their simplified forms, the runes at the same index are a pair
+const (
+	traditionalChars = "萬與專業東絲丟兩嚴喪個豐臨為麗舉義烏樂喬習鄉書買亂爭於虧雲亞產畝親億僅從侖倉儀們" +
+		"價眾優會傘偉傳傷倫偽體餘傭僉俠侶僥偵側僑儈儕儂儲兒黨蘭關興養獸內岡冊寫軍農馮衝決" +
+		"況凍淨涼減湊凜幾鳳憑凱擊鑿劃劉則剛創刪別剎劑劍劇勸辦務動勵勁勞勢勳勻區醫華協單賣" +
+		"盧衛卻廠廳歷厲壓厭縣參雙發變敘臺葉號嘆嘰後嚇呂嗎噸聽啟吳吶嘔員嗆嗚詠響啞噴嚨鹹團" +
+		"園圍圖國圓聖場壞塊堅壇壩墳墜壟壘墾埡塢報聲殼處備復夠頭誇夾奪奮獎婦媽嫵嬌孫學寧寶" +
+		"實寵審憲宮對尋導將爾塵層屬歲豈嶺島嶽巖帥師帳幣帶幫乾廣莊慶廬庫應廟廢開異棄張彌彎" +
+		"彈強歸當錄徹徑憶懷態總戀惡惱驚慣憤願懶戰戲撲執擴掃揚擾撫拋搶護擔擁擇擠揮損換據擺" +
+		"搖數斷無舊時曠暢暫曬朧術機殺雜權條來楊極構槍標樣樹橋檢歐歡氣漢湯溝沒滬潔淚濟渾濃" +
+		"淺測灣滅燈災煙熱爺牽犧狀猶獨獄貓瑪環現畫瘋療盜監盤睜礦碼確禮禍離種積稱穩窮競筆節" +
+		"範築簡糧緊紅級紀約純紙線練組細終經結給絡統絕網綠維緒續罰罵羅聯職聞腦膽臉藥蘇蘋虛" +
+		"蟲補裝見觀規視覺計訂認討讓記許論設訪證評識詞試詩話該語誤說請讀課誰調談謝貝負財貢" +
+		"貨質購貴費賭賊資賽贏趕趙跡踐車軟輕載輸轉辭邊達遷過運還這進遠違連遲適選遺鄰鄭醜釋" +
+		"針銀錢錯鍵鐵長門閃閉問間閱隊陽陰陣階際陸隨險隱隻難雞電靈靜韓頁頂項順須預領頻題顏" +
+		"額風飛飯飲馬駕騙驗髮鬥鬧魚鳥鴨麥黃點齊齒龍龜傢屍姦賤貪議襲錶鐘週麵裡颱鬱髒蔔穀闆" +
+		"製係繫嘗醃佔瀏覽訊縮濾屆贊讚攝彙匯檯閒衊鬆蘿蔣"
+	simplifiedChars = "万与专业东丝丢两严丧个丰临为丽举义乌乐乔习乡书买乱争于亏云亚产亩亲亿仅从仑仓仪们" +
+		"价众优会伞伟传伤伦伪体余佣佥侠侣侥侦侧侨侩侪侬储儿党兰关兴养兽内冈册写军农冯冲决" +
+		"况冻净凉减凑凛几凤凭凯击凿划刘则刚创删别刹剂剑剧劝办务动励劲劳势勋匀区医华协单卖" +
+		"卢卫却厂厅历厉压厌县参双发变叙台叶号叹叽后吓吕吗吨听启吴呐呕员呛呜咏响哑喷咙咸团" +
+		"园围图国圆圣场坏块坚坛坝坟坠垄垒垦垭坞报声壳处备复够头夸夹夺奋奖妇妈妩娇孙学宁宝" +
+		"实宠审宪宫对寻导将尔尘层属岁岂岭岛岳岩帅师帐币带帮干广庄庆庐库应庙废开异弃张弥弯" +
+		"弹强归当录彻径忆怀态总恋恶恼惊惯愤愿懒战戏扑执扩扫扬扰抚抛抢护担拥择挤挥损换据摆" +
+		"摇数断无旧时旷畅暂晒胧术机杀杂权条来杨极构枪标样树桥检欧欢气汉汤沟没沪洁泪济浑浓" +
+		"浅测湾灭灯灾烟热爷牵牺状犹独狱猫玛环现画疯疗盗监盘睁矿码确礼祸离种积称稳穷竞笔节" +
+		"范筑简粮紧红级纪约纯纸线练组细终经结给络统绝网绿维绪续罚骂罗联职闻脑胆脸药苏苹虚" +
+		"虫补装见观规视觉计订认讨让记许论设访证评识词试诗话该语误说请读课谁调谈谢贝负财贡" +
+		"货质购贵费赌贼资赛赢赶赵迹践车软轻载输转辞边达迁过运还这进远违连迟适选遗邻郑丑释" +
+		"针银钱错键铁长门闪闭问间阅队阳阴阵阶际陆随险隐只难鸡电灵静韩页顶项顺须预领频题颜" +
+		"额风飞饭饮马驾骗验发斗闹鱼鸟鸭麦黄点齐齿龙龟家尸奸贱贪议袭表钟周面里台郁脏卜谷板" +
+		"制系系尝腌占浏览讯缩滤届赞赞摄汇汇台闲蔑松萝蒋"
+)
+
+var traditionalToSimplified = func() map[rune]rune {
+	var (
+		trad  = []rune(traditionalChars)
+		simp  = []rune(simplifiedChars)
+		table = make(map[rune]rune, len(trad))
+	)
+	for i, r := range trad {
+		table[r] = simp[i]
+	}
+	return table
+}()
@@ -0,0 +1,103 @@
+package textnorm
+
+import "unicode"
+
+// confusables maps the characters looking like latin letters or digits to them, they are only
+// folded in the words mixing them with latin letters, so the cyrillic, greek or chinese text is kept
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w', 'ѵ': 'v',
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O', 'Р': 'P',
+	'С': 'C', 'Т': 'T', 'Х': 'X', 'І': 'I', 'Ј': 'J', 'Ѕ': 'S', 'Ү': 'Y',
+	// greek
+	'α': 'a', 'ο': 'o', 'ν': 'v', 'ρ': 'p', 'τ': 't', 'ι': 'i', 'κ': 'k', 'υ': 'u',
+	'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I', 'Κ': 'K', 'Μ': 'M',
+	'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y', 'Χ': 'X',
+	// latin lookalikes
+	'ı': 'i', 'ɡ': 'g', 'ℓ': 'l', 'ⅰ': 'i', 'ⅴ': 'v', 'ⅹ': 'x', 'Ⅰ': 'I', 'Ⅴ': 'V', 'Ⅹ': 'X',
+	// superscript and subscript digits
+	'⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4', '⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9',
+	'₀': '0', '₁': '1', '₂': '2', '₃': '3', '₄': '4', '₅': '5', '₆': '6', '₇': '7', '₈': '8', '₉': '9',
+	'⓪': '0', '〇': '0',
+}
+
+// foldConfusable returns the latin letter or digit r looks like by extra and the variants of
+// ascii characters, which are folded in any text
+func foldConfusable(r rune, extra map[rune]rune) rune {
+	if v, ok := extra[r]; ok {
+		return v
+	}
+
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E:
+		// full-width ASCII variants
+		return r - 0xFEE0
+	case r >= 0x2460 && r <= 0x2468:
+		// circled digits ① - ⑨
+		return '1' + r - 0x2460
+	case r >= 0x2474 && r <= 0x247C:
+		// parenthesized digits ⑴ - ⑼
+		return '1' + r - 0x2474
+	case r >= 0x2488 && r <= 0x2490:
+		// digits with full stop ⒈ - ⒐
+		return '1' + r - 0x2488
+	case r >= 0x2776 && r <= 0x277E:
+		// dingbat negative circled digits ❶ - ❾
+		return '1' + r - 0x2776
+	case r >= 0x24B6 && r <= 0x24CF:
+		// circled capital letters Ⓐ - Ⓩ
+		return 'A' + r - 0x24B6
+	case r >= 0x24D0 && r <= 0x24E9:
+		// circled small letters ⓐ - ⓩ
+		return 'a' + r - 0x24D0
+	case r >= 0x1D400 && r <= 0x1D6A3:
+		// mathematical alphanumeric letters, A-Z and a-z repeated in every style
+		if i := (r - 0x1D400) % 52; i < 26 {
+			return 'A' + i
+		}
+		return 'a' + (r-0x1D400)%52 - 26
+	case r >= 0x1D7CE && r <= 0x1D7FF:
+		// mathematical digits
+		return '0' + (r-0x1D7CE)%10
+	}
+	return r
+}
+
+// foldHomoglyphs folds the built-in confusables of the words mixing them with latin letters,
+// such as "раypal" with cyrillic "ра", the words without latin letters are kept
+func foldHomoglyphs(tokens []token) {
+	for i := 0; i < len(tokens); {
+		if !isHomoglyphWord(tokens[i].r) {
+			i++
+			continue
+		}
+		var (
+			j            = i
+			latin, mixed bool
+		)
+		for ; j < len(tokens) && isHomoglyphWord(tokens[j].r); j++ {
+			if unicode.Is(unicode.Latin, tokens[j].r) {
+				latin = true
+			} else if _, ok := confusables[tokens[j].r]; ok {
+				mixed = true
+			}
+		}
+		if latin && mixed {
+			for k := i; k < j; k++ {
+				if v, ok := confusables[tokens[k].r]; ok {
+					tokens[k].r = v
+				}
+			}
+		}
+		i = j
+	}
+}
+
+func isHomoglyphWord(r rune) bool {
+	if _, ok := confusables[r]; ok {
+		return true
+	}
+	return isWordRune(r)
+}
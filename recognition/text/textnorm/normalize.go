@@ -0,0 +1,316 @@
+// Package textnorm provide normalization of obfuscated text before TUPU text recognition
+package textnorm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultRepeatThreshold is the default min length of a run of the same character collapsed into one
+	DefaultRepeatThreshold = 3
+	// DefaultSpacedRun is the default min number of spaced-out single characters joined together
+	DefaultSpacedRun = 3
+)
+
+var (
+	urlPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s]+|\b[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|cn|net|org|io|cc|top|xyz|me|vip|info|club|site|online|tk)\b(?:/[^\s]*)?`)
+	// mainland mobile and landline numbers, separated by '-' or ' ' optionally
+	phonePattern = regexp.MustCompile(`(?:\+86[- ]?|\b86[- ]?|\b)1[3-9]\d[- ]?\d{4}[- ]?\d{4}\b|\b0\d{2,3}-?\d{7,8}\b`)
+)
+
+type (
+	// Normalizer folds the obfuscated characters of text
+	Normalizer struct {
+		repeatThreshold int
+		repeatScripts   []*unicode.RangeTable
+		spacedRun       int
+		confusables     map[rune]rune
+	}
+
+	// NormOptFunc is a function to set the option of Normalizer
+	NormOptFunc func(*Normalizer)
+
+	// Entity is an url or a phone number found in the text, Start and End are rune offsets in the original text
+	Entity struct {
+		Value string
+		Start int
+		End   int
+	}
+
+	// Result is the normalized text of Normalizer.Normalize
+	Result struct {
+		Original string
+		Text     string
+		URLs     []Entity
+		Phones   []Entity
+		// starts and ends are the original rune range of every rune in Text
+		starts []int
+		ends   []int
+	}
+
+	token struct {
+		r     rune
+		start int
+		end   int
+	}
+)
+
+// WithRepeatThreshold sets the min length of a run of the same letter collapsed into one, 0 disables it.
+// Digits are never collapsed, so phone numbers are kept
+func WithRepeatThreshold(n int) NormOptFunc {
+	return func(n0 *Normalizer) {
+		n0.repeatThreshold = n
+	}
+}
+
+// WithRepeatScripts sets the scripts whose letters are collapsed, unicode.Latin by default.
+// The repeats of other scripts are kept, such as "哈哈哈" in chinese
+func WithRepeatScripts(scripts ...*unicode.RangeTable) NormOptFunc {
+	return func(n *Normalizer) {
+		n.repeatScripts = scripts
+	}
+}
+
+// WithSpacedRun sets the min number of spaced-out single characters joined together, 0 disables it
+func WithSpacedRun(n int) NormOptFunc {
+	return func(n0 *Normalizer) {
+		n0.spacedRun = n
+	}
+}
+
+// WithConfusables adds the characters folded to another one in any text, they take precedence over
+// the built-in ones, which are only folded in the words mixing them with latin letters
+func WithConfusables(confusables map[rune]rune) NormOptFunc {
+	return func(n *Normalizer) {
+		if n.confusables == nil {
+			n.confusables = make(map[rune]rune, len(confusables))
+		}
+		for k, v := range confusables {
+			n.confusables[k] = v
+		}
+	}
+}
+
+// NewNormalizer is an initializer for a Normalizer
+func NewNormalizer(opts ...NormOptFunc) *Normalizer {
+	n := &Normalizer{
+		repeatThreshold: DefaultRepeatThreshold,
+		repeatScripts:   []*unicode.RangeTable{unicode.Latin},
+		spacedRun:       DefaultSpacedRun,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Normalize strips the invisible characters of content, folds full-width characters and the
+// homoglyphs of mixed-script words, joins spaced-out characters, collapses repeated letters and
+// extracts urls and phone numbers
+func (n *Normalizer) Normalize(content string) *Result {
+	// step1. strip invisible characters and fold the variants of ascii characters
+	tokens := make([]token, 0, len(content))
+	offset := 0
+	for _, r := range content {
+		if !isInvisible(r) {
+			tokens = append(tokens, token{r: foldConfusable(r, n.confusables), start: offset, end: offset + 1})
+		}
+		offset++
+	}
+
+	// step2. join spaced-out characters, such as "v x 1 2 3", and fold the homoglyphs of the words
+	// mixing scripts with latin
+	if n.spacedRun > 1 {
+		tokens = joinSpaced(tokens, n.spacedRun)
+	}
+	foldHomoglyphs(tokens)
+
+	// step3. extract urls and phone numbers, they are kept as is in the next step
+	var (
+		text      = tokensString(tokens)
+		protected = make([]bool, len(tokens))
+		urls      = extract(urlPattern, text, tokens, protected, false)
+		phones    = extract(phonePattern, text, tokens, protected, true)
+	)
+
+	// step4. collapse repeated letters, such as "fuuuck"
+	if n.repeatThreshold > 1 {
+		tokens = collapseRepeats(tokens, protected, n.repeatThreshold, n.repeatScripts)
+	}
+
+	r := &Result{
+		Original: content,
+		Text:     tokensString(tokens),
+		URLs:     urls,
+		Phones:   phones,
+		starts:   make([]int, len(tokens)),
+		ends:     make([]int, len(tokens)),
+	}
+	for i, t := range tokens {
+		r.starts[i], r.ends[i] = t.start, t.end
+	}
+	return r
+}
+
+// OriginalRange maps the rune range [start, end) of Text to the rune range of Original,
+// -1 is returned if the range is invalid
+func (r *Result) OriginalRange(start, end int) (int, int) {
+	if start < 0 || end <= start || end > len(r.starts) {
+		return -1, -1
+	}
+	return r.starts[start], r.ends[end-1]
+}
+
+// Changed reports whether Text is different from Original
+func (r *Result) Changed() bool {
+	return r.Text != r.Original
+}
+
+func tokensString(tokens []token) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteRune(t.r)
+	}
+	return sb.String()
+}
+
+// extract finds pattern in text built from tokens, and marks the tokens of the found entities protected
+func extract(pattern *regexp.Regexp, text string, tokens []token, protected []bool, digitsOnly bool) []Entity {
+	var entities []Entity
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		var (
+			value = strings.TrimRight(text[loc[0]:loc[1]], ".,;!?)")
+			start = utf8.RuneCountInString(text[:loc[0]])
+			end   = start + utf8.RuneCountInString(value)
+		)
+		if end <= start {
+			continue
+		}
+		for i := start; i < end; i++ {
+			protected[i] = true
+		}
+		if digitsOnly {
+			value = strings.Map(func(c rune) rune {
+				if c == '+' || (c >= '0' && c <= '9') {
+					return c
+				}
+				return -1
+			}, value)
+		}
+		entities = append(entities, Entity{Value: value, Start: tokens[start].start, End: tokens[end-1].end})
+	}
+	return entities
+}
+
+func isInvisible(r rune) bool {
+	switch r {
+	case 0x115F, 0x1160, 0x3164, 0xFFA0:
+		// hangul fillers
+		return true
+	}
+	return unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Me, r) || isCombiningMark(r) ||
+		(unicode.IsControl(r) && !unicode.IsSpace(r))
+}
+
+// isCombiningMark reports whether r is a diacritic or variation selector stacked on letters,
+// the vowel signs of scripts such as Thai are kept
+func isCombiningMark(r rune) bool {
+	return (r >= 0x0300 && r <= 0x036F) || (r >= 0x1AB0 && r <= 0x1AFF) ||
+		(r >= 0x1DC0 && r <= 0x1DFF) || (r >= 0x20D0 && r <= 0x20FF) ||
+		(r >= 0xFE00 && r <= 0xFE0F) || (r >= 0xFE20 && r <= 0xFE2F) ||
+		(r >= 0xE0100 && r <= 0xE01EF)
+}
+
+func isSpacer(r rune) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+	switch r {
+	case '.', '-', '_', '*', '·', '•', '|', '/', '\\', '~', '+', '^', '=', '。', '、':
+		return true
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// joinSpaced drops the spacers between single characters if there are at least minRun of them
+func joinSpaced(tokens []token, minRun int) []token {
+	var (
+		out  = make([]token, 0, len(tokens))
+		i    = 0
+		size = len(tokens)
+	)
+	// single reports whether tokens[j] is a word rune standing alone
+	single := func(j int) bool {
+		return isWordRune(tokens[j].r) &&
+			(j == 0 || !isWordRune(tokens[j-1].r)) &&
+			(j == size-1 || !isWordRune(tokens[j+1].r))
+	}
+
+	for i < size {
+		if !single(i) {
+			out = append(out, tokens[i])
+			i++
+			continue
+		}
+
+		// collect the run of single characters separated by at most 2 spacers
+		var (
+			singles = []int{i}
+			j       = i + 1
+		)
+		for j < size {
+			k := j
+			for k < size && k-j < 2 && isSpacer(tokens[k].r) {
+				k++
+			}
+			if k == j || k >= size || !single(k) {
+				break
+			}
+			singles = append(singles, k)
+			j = k + 1
+		}
+
+		if len(singles) < minRun {
+			out = append(out, tokens[i])
+			i++
+			continue
+		}
+		for _, idx := range singles {
+			out = append(out, tokens[idx])
+		}
+		i = singles[len(singles)-1] + 1
+	}
+	return out
+}
+
+// collapseRepeats collapses a run of at least threshold same letters of scripts into one, the protected tokens are kept
+func collapseRepeats(tokens []token, protected []bool, threshold int, scripts []*unicode.RangeTable) []token {
+	out := make([]token, 0, len(tokens))
+	for i := 0; i < len(tokens); {
+		j := i + 1
+		for j < len(tokens) && tokens[j].r == tokens[i].r && protected[j] == protected[i] {
+			j++
+		}
+		if protected[i] {
+			out = append(out, tokens[i:j]...)
+			i = j
+			continue
+		}
+		if j-i >= threshold && unicode.IsLetter(tokens[i].r) && unicode.IsOneOf(scripts, tokens[i].r) {
+			t := tokens[i]
+			t.end = tokens[j-1].end
+			out = append(out, t)
+		} else {
+			out = append(out, tokens[i:j]...)
+		}
+		i = j
+	}
+	return out
+}
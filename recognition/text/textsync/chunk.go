@@ -0,0 +1,437 @@
+package textsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textfilter"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textnorm"
+)
+
+const (
+	// DefaultMaxChunkRunes is the default max number of runes sent in one text
+	DefaultMaxChunkRunes = 5000
+	// DefaultChunkOverlap is the default number of runes repeated at the start of the next chunk,
+	// so a sensitive phrase across the cut point is still recognized
+	DefaultChunkOverlap = 50
+	// DefaultMaxBatchItems is the default max number of texts sent in one request
+	DefaultMaxBatchItems = 100
+	// DefaultMaxBatchRunes is the default max number of runes sent in one request
+	DefaultMaxBatchRunes = 10 * DefaultMaxChunkRunes
+
+	// chunkIDSeparator joins the contentId of the text and the index of the chunk
+	chunkIDSeparator = "#"
+)
+
+type (
+	// Chunk is a part of a long text, Start and End are rune offsets in the text
+	Chunk struct {
+		Index int
+		Start int
+		End   int
+		Text  string
+	}
+
+	// ItemVerdict is the result of a text merged from the results of its chunks
+	ItemVerdict struct {
+		ContentID string
+		// Chunks is the number of chunks the text is split into
+		Chunks int
+		// Tasks is the merged result of every task, key is the task id, offsets of
+		// Details refer to the original text, -1 means the offset is unknown
+		Tasks map[string]*ItemResult
+		// Local is true if the text is judged by the prefilter without calling TUPU
+		Local bool
+		// LocalHits is the keywords found by the prefilter
+		LocalHits []textfilter.Hit
+		// URLs and Phones are extracted by the normalizer
+		URLs   []textnorm.Entity
+		Phones []textnorm.Entity
+		// Cached is true if the verdict is found in the cache
+		Cached bool
+	}
+
+	// chunkRef locates the result of a chunk, pos is the index of the chunk in its batch
+	chunkRef struct {
+		batch int
+		pos   int
+		id    string
+	}
+
+	// cachedVerdict is the part of ItemVerdict saved in the cache
+	cachedVerdict struct {
+		Chunks int                    `json:"chunks"`
+		Tasks  map[string]*ItemResult `json:"tasks"`
+	}
+)
+
+// SplitText splits content into chunks of at most maxRunes runes, a chunk is cut at the end of
+// a sentence if possible, and the next chunk starts overlap runes before the cut point
+func SplitText(content string, maxRunes, overlap int) []Chunk {
+	runes := []rune(content)
+	if maxRunes <= 0 || len(runes) <= maxRunes {
+		return []Chunk{{Index: 0, Start: 0, End: len(runes), Text: content}}
+	}
+	if overlap < 0 || overlap > maxRunes/4 {
+		overlap = maxRunes / 4
+	}
+
+	var (
+		chunks []Chunk
+		start  = 0
+	)
+	for {
+		end := start + maxRunes
+		if end >= len(runes) {
+			chunks = append(chunks, Chunk{Index: len(chunks), Start: start, End: len(runes), Text: string(runes[start:])})
+			return chunks
+		}
+
+		end = cutPoint(runes, start+maxRunes/2, end)
+		chunks = append(chunks, Chunk{Index: len(chunks), Start: start, End: end, Text: string(runes[start:end])})
+		start = end - overlap
+	}
+}
+
+// SetChunkOptions provide setting the max runes and overlap runes of the chunks used by Moderate
+func (syncHdler *SyncHandler) SetChunkOptions(maxRunes, overlap int) {
+	syncHdler.maxChunkRunes = maxRunes
+	syncHdler.chunkOverlap = overlap
+}
+
+// SetBatchOptions provide setting the max texts and runes sent in one request by Moderate
+func (syncHdler *SyncHandler) SetBatchOptions(maxItems, maxRunes int) {
+	syncHdler.maxBatchItems = maxItems
+	syncHdler.maxBatchRunes = maxRunes
+}
+
+// Moderate recognizes texts like Perform, but a long text is split into chunks sent under
+// derived contentIds, and the results of the chunks are merged into one verdict per text.
+// The texts and chunks are sent in batches within the batch options, a text short enough
+// keeps its contentId.
+// If a normalizer is set, the normalized texts are filtered and sent instead of the original ones.
+// If a prefilter is set, the texts blocked by it are judged locally and not sent to TUPU, the hits
+// of annotate lists are sent in the Annotations of the texts.
+// If a cache is set, the verdicts of the texts recognized before are taken from it
+func (syncHdler *SyncHandler) Moderate(secretID string, texts []TextAsyncItem) (verdicts []*ItemVerdict, statusCode int, err error) {
+	if tupuerror.StringIsEmpty(secretID) || len(texts) == 0 {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+
+	var (
+		maxRunes      = syncHdler.maxChunkRunes
+		overlap       = syncHdler.chunkOverlap
+		maxBatchItems = syncHdler.maxBatchItems
+		maxBatchRunes = syncHdler.maxBatchRunes
+		chunkSets     = make([][]Chunk, len(texts))
+		chunkRefs     = make([][]chunkRef, len(texts))
+		batches       [][]TextAsyncItem
+		batchRunes    = 0
+		hitSets       = make([][]textfilter.Hit, len(texts))
+		norms         = make([]*textnorm.Result, len(texts))
+		cacheKeys     = make([]string, len(texts))
+	)
+	if maxRunes == 0 {
+		maxRunes, overlap = DefaultMaxChunkRunes, DefaultChunkOverlap
+	}
+	if maxBatchItems <= 0 {
+		maxBatchItems = DefaultMaxBatchItems
+	}
+	if maxBatchRunes <= 0 {
+		maxBatchRunes = DefaultMaxBatchRunes
+	}
+
+	verdicts = make([]*ItemVerdict, len(texts))
+
+	// step1. normalize and check texts with the prefilter, and split the rest texts into chunks
+	for i, text := range texts {
+		if syncHdler.normalizer != nil {
+			norms[i] = syncHdler.normalizer.Normalize(text.Content)
+			text.Content = norms[i].Text
+		}
+		if syncHdler.prefilter != nil {
+			v := syncHdler.prefilter.Check(text.Content)
+			if v.Blocked {
+				verdicts[i] = newLocalVerdict(text.ContentID, v)
+				continue
+			}
+			hitSets[i] = v.Hits
+		}
+		if syncHdler.cache != nil {
+			cacheKeys[i] = tupucache.Key(TextSyncAPIURL, secretID, text.Content)
+			if verdict := syncHdler.loadVerdict(cacheKeys[i]); verdict != nil {
+				verdict.ContentID, verdict.LocalHits = text.ContentID, hitSets[i]
+				verdicts[i] = verdict
+				continue
+			}
+		}
+
+		baseID := text.ContentID
+		if len(baseID) == 0 {
+			baseID = fmt.Sprint(i)
+		}
+		chunkSets[i] = SplitText(text.Content, maxRunes, overlap)
+		for _, chunk := range chunkSets[i] {
+			item := text
+			item.Content = chunk.Text
+			item.Annotations = chunkAnnotations(hitSets[i], chunk)
+			if len(chunkSets[i]) > 1 {
+				item.ContentID = baseID + chunkIDSeparator + fmt.Sprint(chunk.Index)
+			}
+
+			// step2. put the chunk into the last batch, or a new one if it is full
+			runes := chunk.End - chunk.Start
+			if last := len(batches) - 1; last < 0 || len(batches[last]) >= maxBatchItems ||
+				(len(batches[last]) > 0 && batchRunes+runes > maxBatchRunes) {
+				batches = append(batches, make([]TextAsyncItem, 0, maxBatchItems))
+				batchRunes = 0
+			}
+			last := len(batches) - 1
+			chunkRefs[i] = append(chunkRefs[i], chunkRef{batch: last, pos: len(batches[last]), id: item.ContentID})
+			batches[last] = append(batches[last], item)
+			batchRunes += runes
+		}
+	}
+
+	// the offsets of the verdicts refer to the original texts
+	defer func() {
+		for i, verdict := range verdicts {
+			if verdict != nil && norms[i] != nil {
+				restoreOffsets(verdict, norms[i])
+			}
+		}
+	}()
+
+	// all texts are judged locally
+	statusCode = http.StatusOK
+	if len(batches) == 0 {
+		return
+	}
+
+	// step3. recognize the batches one by one
+	textResults := make([]*TextResult, len(batches))
+	for b, items := range batches {
+		var result string
+		if result, statusCode, err = syncHdler.Perform(secretID, items); err != nil {
+			return
+		}
+		if textResults[b], err = ParseTextResult(result); err != nil {
+			return
+		}
+		if statusCode > 299 || textResults[b].Code != 0 {
+			err = fmt.Errorf("status code: %d, code: %d, message: %s", statusCode, textResults[b].Code, textResults[b].Message)
+			return
+		}
+	}
+
+	// step4. merge the results of chunks
+	for i, text := range texts {
+		if verdicts[i] != nil {
+			continue
+		}
+		verdicts[i] = mergeChunks(text.ContentID, chunkSets[i], chunkRefs[i], textResults)
+		verdicts[i].LocalHits = hitSets[i]
+		if len(cacheKeys[i]) > 0 {
+			syncHdler.saveVerdict(cacheKeys[i], verdicts[i])
+		}
+	}
+	return
+}
+
+func (syncHdler *SyncHandler) loadVerdict(key string) *ItemVerdict {
+	val, ok := syncHdler.cache.Get(key)
+	if !ok {
+		return nil
+	}
+	var cv cachedVerdict
+	if json.Unmarshal([]byte(val), &cv) != nil {
+		return nil
+	}
+	return &ItemVerdict{Chunks: cv.Chunks, Tasks: cv.Tasks, Cached: true}
+}
+
+// saveVerdict saves the verdict before its offsets are restored, the offsets refer to the
+// normalized text which is the cache key
+func (syncHdler *SyncHandler) saveVerdict(key string, verdict *ItemVerdict) {
+	if len(verdict.Tasks) == 0 {
+		return
+	}
+	if val, err := json.Marshal(cachedVerdict{Chunks: verdict.Chunks, Tasks: verdict.Tasks}); err == nil {
+		syncHdler.cache.Set(key, string(val), syncHdler.cacheTTL)
+	}
+}
+
+func mergeChunks(contentID string, chunks []Chunk, refs []chunkRef, textResults []*TextResult) *ItemVerdict {
+	verdict := &ItemVerdict{
+		ContentID: contentID,
+		Chunks:    len(chunks),
+		Tasks:     make(map[string]*ItemResult),
+	}
+
+	taskIDs := make(map[string]bool)
+	for _, ref := range refs {
+		for taskID := range textResults[ref.batch].Tasks {
+			taskIDs[taskID] = true
+		}
+	}
+
+	for taskID := range taskIDs {
+		var (
+			merged = &ItemResult{ContentID: contentID}
+			seen   = make(map[string]bool)
+			found  = false
+		)
+		for i, chunk := range chunks {
+			task, ok := textResults[refs[i].batch].Tasks[taskID]
+			if !ok {
+				continue
+			}
+			var res *ItemResult
+			if len(refs[i].id) > 0 {
+				res = task.Find(refs[i].id)
+			}
+			// results without contentId are in the order of the request
+			if pos := refs[i].pos; res == nil && pos < len(task.Results) && len(task.Results[pos].ContentID) == 0 {
+				res = task.Results[pos]
+			}
+			if res == nil {
+				continue
+			}
+			found = true
+			mergeItemResult(merged, res, chunk, seen)
+		}
+		if found {
+			sort.SliceStable(merged.Details, func(a, b int) bool {
+				return merged.Details[a].Start < merged.Details[b].Start
+			})
+			verdict.Tasks[taskID] = merged
+		}
+	}
+	return verdict
+}
+
+// mergeItemResult keeps the most confident violation label of the chunks
+func mergeItemResult(merged, res *ItemResult, chunk Chunk, seen map[string]bool) {
+	merged.Review = merged.Review || res.Review
+	switch {
+	case res.Label != 0 && (merged.Label == 0 || res.Rate > merged.Rate):
+		merged.Label, merged.Rate = res.Label, res.Rate
+	case merged.Label == 0 && res.Label == 0 && res.Rate > merged.Rate:
+		merged.Rate = res.Rate
+	}
+
+	for _, d := range res.Details {
+		detail := *d
+		locateDetail(&detail, chunk)
+		// a hit in the overlap of two chunks is reported by both of them
+		key := fmt.Sprintf("%d:%d:%d:%s", detail.Start, detail.End, detail.Label, detail.Keyword)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged.Details = append(merged.Details, &detail)
+	}
+}
+
+func locateDetail(detail *Detail, chunk Chunk) {
+	if detail.End > detail.Start {
+		detail.Start += chunk.Start
+		detail.End += chunk.Start
+		return
+	}
+	if idx := strings.Index(chunk.Text, detail.Keyword); len(detail.Keyword) > 0 && idx >= 0 {
+		detail.Start = chunk.Start + utf8.RuneCountInString(chunk.Text[:idx])
+		detail.End = detail.Start + utf8.RuneCountInString(detail.Keyword)
+		return
+	}
+	detail.Start, detail.End = -1, -1
+}
+
+// chunkAnnotations returns the annotate hits inside chunk, the offsets refer to the chunk
+func chunkAnnotations(hits []textfilter.Hit, chunk Chunk) []Annotation {
+	var annotations []Annotation
+	for _, hit := range hits {
+		if hit.Action != textfilter.ActionAnnotate || hit.Start < chunk.Start || hit.End > chunk.End {
+			continue
+		}
+		annotations = append(annotations, Annotation{
+			List:    hit.List,
+			Label:   hit.Label,
+			Keyword: hit.Keyword,
+			Start:   hit.Start - chunk.Start,
+			End:     hit.End - chunk.Start,
+		})
+	}
+	return annotations
+}
+
+func cutPoint(runes []rune, min, max int) int {
+	for i := max; i > min; i-- {
+		if isSentenceEnd(runes[i-1]) {
+			return i
+		}
+	}
+	for i := max; i > min; i-- {
+		if unicode.IsSpace(runes[i-1]) || unicode.IsPunct(runes[i-1]) {
+			return i
+		}
+	}
+	return max
+}
+
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '。', '！', '？', '；', '…', '!', '?', ';', '.', '\n':
+		return true
+	default:
+		return false
+	}
+}
+
+func newLocalVerdict(contentID string, v *textfilter.Verdict) *ItemVerdict {
+	r := &ItemResult{ContentID: contentID, Rate: 1}
+	for _, hit := range v.Hits {
+		if hit.Action == textfilter.ActionBlock && r.Label == 0 {
+			r.Label = hit.Label
+		}
+		r.Details = append(r.Details, &Detail{
+			Keyword: hit.Keyword,
+			Hint:    hit.List,
+			Label:   hit.Label,
+			Start:   hit.Start,
+			End:     hit.End,
+		})
+	}
+	return &ItemVerdict{
+		ContentID: contentID,
+		Chunks:    0,
+		Tasks:     map[string]*ItemResult{textfilter.LocalTaskID: r},
+		Local:     true,
+		LocalHits: v.Hits,
+	}
+}
+
+// restoreOffsets maps the offsets of verdict in the normalized text to the original text
+func restoreOffsets(verdict *ItemVerdict, norm *textnorm.Result) {
+	verdict.URLs, verdict.Phones = norm.URLs, norm.Phones
+	for _, r := range verdict.Tasks {
+		for _, d := range r.Details {
+			if d.Start >= 0 {
+				d.Start, d.End = norm.OriginalRange(d.Start, d.End)
+			}
+		}
+	}
+	for i := range verdict.LocalHits {
+		hit := &verdict.LocalHits[i]
+		hit.Start, hit.End = norm.OriginalRange(hit.Start, hit.End)
+	}
+}
@@ -4,9 +4,14 @@ package textsync
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
 	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textfilter"
+	"github.com/tuputech/tupu-go-sdk/recognition/text/textnorm"
 )
 
 const (
@@ -16,7 +21,15 @@ const (
 
 // SyncHandler is a client-side helper to access TUPU sync text recognition service
 type SyncHandler struct {
-	hdler *tupucontrol.Handler
+	hdler         *tupucontrol.Handler
+	maxChunkRunes int
+	chunkOverlap  int
+	maxBatchItems int
+	maxBatchRunes int
+	prefilter     *textfilter.Prefilter
+	normalizer    *textnorm.Normalizer
+	cache         tupucache.Cache
+	cacheTTL      time.Duration
 }
 
 // NewTextHandler is an initializer for a SyncHandler.
@@ -45,7 +58,8 @@ func (asyncHdler *SyncHandler) SetServerURL(url string) {
 	asyncHdler.hdler.SetServerURL(url)
 }
 
-// Perform is the major method for initiating a text recognition request
+// Perform is the major method for initiating a text recognition request, the texts are sent as they are,
+// use Moderate to apply the chunk options, normalizer, prefilter and cache of the handler
 func (asyncHdler *SyncHandler) Perform(secretID string, textSync []TextAsyncItem) (result string, statusCode int, err error) {
 
 	// step1. Invalid parameter check
@@ -69,7 +83,36 @@ func (asyncHdler *SyncHandler) Perform(secretID string, textSync []TextAsyncItem
 	return asyncHdler.hdler.RecognizeWithJSON(requestParams, secretID)
 }
 
+// SetPrefilter provide setting the local keyword filter used by Moderate, nil disables it. The texts
+// hitting a block list are judged locally, and the hits of annotate lists are sent with the texts
+func (asyncHdler *SyncHandler) SetPrefilter(prefilter *textfilter.Prefilter) {
+	asyncHdler.prefilter = prefilter
+}
+
+// SetNormalizer provide setting the normalizer used by Moderate, the texts are normalized before
+// filtering and recognition, and the offsets of the results refer to the original texts. nil disables it
+func (asyncHdler *SyncHandler) SetNormalizer(normalizer *textnorm.Normalizer) {
+	asyncHdler.normalizer = normalizer
+}
+
+// SetCache provide setting the cache of the verdicts of Moderate, a text is keyed by its
+// normalized content, so identical texts are not sent again. nil disables it
+func (asyncHdler *SyncHandler) SetCache(cache tupucache.Cache, ttl time.Duration) {
+	asyncHdler.cache = cache
+	asyncHdler.cacheTTL = ttl
+}
+
 // SetTimeout provide properties to set request ttl
 func (asyncHdler *SyncHandler) SetTimeout(timeout int) {
 	asyncHdler.hdler.SetTimeout(timeout)
 }
+
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (asyncHdler *SyncHandler) SetTransport(transport http.RoundTripper) {
+	asyncHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (asyncHdler *SyncHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	asyncHdler.hdler.SetInterceptor(interceptor)
+}
@@ -5,4 +5,15 @@ type TextAsyncItem struct {
 	ContentID string `json:"contentId,omitempty"`
 	UserID    string `json:"userId,omitempty"`
 	ForumID   string `json:"forumId,omitempty"`
+	// Annotations is the keywords found by the prefilter, it is set by Moderate
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// Annotation is a keyword of an annotate list found in the text, Start and End are rune offsets in Content
+type Annotation struct {
+	List    string `json:"list,omitempty"`
+	Label   int    `json:"label"`
+	Keyword string `json:"keyword"`
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
 }
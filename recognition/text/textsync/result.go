@@ -0,0 +1,86 @@
+package textsync
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+type (
+	// TextResult is a wrapper for text sync result parsed from response
+	TextResult struct {
+		Code      int
+		Message   string
+		Timestamp int64
+		Nonce     string
+		// Tasks is the recognition result of every task, key is the task id
+		Tasks map[string]*TaskResult
+		// Raw is the original json string
+		Raw string
+	}
+
+	// TaskResult is the result of a task for all texts in the request
+	TaskResult struct {
+		Results []*ItemResult `json:"results"`
+	}
+
+	// ItemResult is the result of a task for one text
+	ItemResult struct {
+		ContentID string  `json:"contentId,omitempty"`
+		Label     int     `json:"label"`
+		Rate      float64 `json:"rate,omitempty"`
+		Review    bool    `json:"review"`
+		// Details is the hit parts of the text
+		Details []*Detail `json:"details,omitempty"`
+	}
+
+	// Detail is a hit part of the text, Start and End are rune offsets in the text
+	Detail struct {
+		Keyword string `json:"keyword,omitempty"`
+		Hint    string `json:"hint,omitempty"`
+		Label   int    `json:"label,omitempty"`
+		Start   int    `json:"start,omitempty"`
+		End     int    `json:"end,omitempty"`
+	}
+)
+
+// ParseTextResult is a helper to parse json string and create a TextResult struct
+func ParseTextResult(s string) (*TextResult, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil, fmt.Errorf("invalid text result: %v", err)
+	}
+
+	r := &TextResult{
+		Tasks: make(map[string]*TaskResult),
+		Raw:   s,
+	}
+	common, err := tupumodel.ParseResultData(data, func(key string, val interface{}) bool {
+		// a task result is decoded again into TaskResult, other fields are skipped
+		buf, err := json.Marshal(val)
+		if err != nil {
+			return true
+		}
+		task := new(TaskResult)
+		if json.Unmarshal(buf, task) == nil && task.Results != nil {
+			r.Tasks[key] = task
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid text result: %v", err)
+	}
+	r.Code, r.Message, r.Timestamp, r.Nonce = common.Code, common.Message, common.Timestamp, common.Nonce
+	return r, nil
+}
+
+// Find returns the result of contentID in the task
+func (task *TaskResult) Find(contentID string) *ItemResult {
+	for _, item := range task.Results {
+		if item.ContentID == contentID {
+			return item
+		}
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package videoasync
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"sync"
 
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
@@ -15,12 +17,20 @@ const (
 	VideoAsyncResultURL    = "http://api.open.tuputech.com/v3/recognition/video/result/"
 	VideoAsyncCloseTaskURL = "http://api.open.tuputech.com/v3/recognition/video/close/"
 	VideoAsyncQueryRateURL = "http://api.open.tuputech.com/v3/recognition/video/rate/"
+
+	// videoRootURL is the root of the video async apis
+	videoRootURL = "http://api.open.tuputech.com/v3/recognition/video/"
+	scanPath     = "asyncscan/"
+	resultPath   = "result/"
+	closePath    = "close/"
+	ratePath     = "rate/"
 )
 
 // AsyncHandler is a client-side helper to access TUPU speech recognition service
 type AsyncHandler struct {
 	syncPool sync.Pool
 	hdler    *tupucontrol.Handler
+	rootURL  string
 }
 
 // NewASyncHandler is an initializer for a SpeechHandler
@@ -32,7 +42,7 @@ func NewVideoAsyncHandler(privateKeyPath string) (*AsyncHandler, error) {
 
 	var (
 		err        error
-		asyncHdler = new(AsyncHandler)
+		asyncHdler = &AsyncHandler{rootURL: videoRootURL}
 	)
 
 	if asyncHdler.hdler, err = tupucontrol.NewHandlerWithURL(privateKeyPath, VideoAsyncURL); err != nil {
@@ -46,9 +56,15 @@ func NewVideoAsyncHandler(privateKeyPath string) (*AsyncHandler, error) {
 	return asyncHdler, nil
 }
 
-// SetServerURL provide set request server URL attribute
+// SetServerURL provide set request server URL attribute, url is the asyncscan api or the root of
+// the video apis, the result, close and rate apis are under the same root
 func (asyncHdler *AsyncHandler) SetServerURL(url string) {
-	asyncHdler.hdler.SetServerURL(url)
+	if tupuerror.StringIsEmpty(url) {
+		return
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), "/asyncscan")
+	asyncHdler.rootURL = url + "/"
+	asyncHdler.hdler.SetServerURL(asyncHdler.rootURL + scanPath)
 }
 
 func (syncHdler *AsyncHandler) recycleDataObj(videoAsync *VideoAsync) {
@@ -61,6 +77,16 @@ func (asyncHdler *AsyncHandler) SetTimeout(timeout int) {
 	asyncHdler.hdler.SetTimeout(timeout)
 }
 
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (asyncHdler *AsyncHandler) SetTransport(transport http.RoundTripper) {
+	asyncHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (asyncHdler *AsyncHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	asyncHdler.hdler.SetInterceptor(interceptor)
+}
+
 // Perform is the major method for initiating a recognition request
 func (asyncHdler *AsyncHandler) Perform(secretID, videoUrl, callbackUrl string, optFuncs ...AsyncOptFunc) (result string, statusCode int, err error) {
 
@@ -77,7 +103,6 @@ func (asyncHdler *AsyncHandler) Perform(secretID, videoUrl, callbackUrl string,
 		paramsStr     string
 	)
 
-	asyncHdler.hdler.SetServerURL(VideoAsyncURL)
 	videoAsync = asyncHdler.syncPool.Get().(*VideoAsync)
 	defer asyncHdler.recycleDataObj(videoAsync)
 
@@ -94,19 +119,17 @@ func (asyncHdler *AsyncHandler) Perform(secretID, videoUrl, callbackUrl string,
 
 	paramsStr = string(requestParams[1 : len(requestParams)-1])
 	// step3. transfer general api
-	return asyncHdler.hdler.RecognizeWithJSON(paramsStr, secretID)
+	return asyncHdler.hdler.RecognizeWithJSONAndURL(asyncHdler.rootURL+scanPath, paramsStr, secretID)
 }
 
 // CloseRecognitionTask can close your video recognition task
 func (asyncHdler *AsyncHandler) CloseRecognitionTask(secretID, videoId string) (result string, statusCode int, err error) {
-	asyncHdler.SetServerURL(VideoAsyncCloseTaskURL)
-	return asyncHdler.closeOrQueryVideoInfo(secretID, videoId)
+	return asyncHdler.closeOrQueryVideoInfo(asyncHdler.rootURL+closePath, secretID, videoId)
 }
 
 // QueryRecognitionResult can query your video recognition result
 func (asyncHdler *AsyncHandler) QueryRecognitionResult(secretID, videoId string) (result string, statusCode int, err error) {
-	asyncHdler.SetServerURL(VideoAsyncResultURL)
-	return asyncHdler.closeOrQueryVideoInfo(secretID, videoId)
+	return asyncHdler.closeOrQueryVideoInfo(asyncHdler.rootURL+resultPath, secretID, videoId)
 }
 
 // QueryRecognitionResult can query video recognition rate for your secretId
@@ -116,12 +139,10 @@ func (asyncHdler *AsyncHandler) QueryRate(secretID string) (result string, statu
 		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
 		return
 	}
-	asyncHdler.SetServerURL(VideoAsyncQueryRateURL)
-
-	return asyncHdler.hdler.RecognizeWithJSON("{}", secretID)
+	return asyncHdler.hdler.RecognizeWithJSONAndURL(asyncHdler.rootURL+ratePath, "{}", secretID)
 }
 
-func (asyncHdler *AsyncHandler) closeOrQueryVideoInfo(secretID, videoId string) (result string, statusCode int, err error) {
+func (asyncHdler *AsyncHandler) closeOrQueryVideoInfo(apiURL, secretID, videoId string) (result string, statusCode int, err error) {
 	if tupuerror.StringIsEmpty(secretID, videoId) {
 		statusCode = 400
 		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
@@ -129,5 +150,5 @@ func (asyncHdler *AsyncHandler) closeOrQueryVideoInfo(secretID, videoId string)
 	}
 
 	requestParams := `"videoId": "` + videoId + `"`
-	return asyncHdler.hdler.RecognizeWithJSON(requestParams, secretID)
+	return asyncHdler.hdler.RecognizeWithJSONAndURL(apiURL, requestParams, secretID)
 }
@@ -0,0 +1,518 @@
+package videoasync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupujobstore "github.com/tuputech/tupu-go-sdk/lib/jobstore"
+)
+
+const (
+	// DefaultPollInterval is the first interval to query the result of a job
+	DefaultPollInterval = 5 * time.Second
+	// DefaultMaxPollInterval is the upper limit of the exponential polling interval
+	DefaultMaxPollInterval = 2 * time.Minute
+	// DefaultMaxConcurrency is used when QueryRate can't tell the rate of the secretId
+	DefaultMaxConcurrency = 10
+	// DefaultJobTimeout is the longest time a job is tracked before it is given up
+	DefaultJobTimeout = 24 * time.Hour
+)
+
+var (
+	// ErrJobCancelled is the error of a job closed by Cancel
+	ErrJobCancelled = errors.New("video recognition job is cancelled")
+	// ErrManagerClosed is the error of a job which is still running when the JobManager is closed
+	ErrManagerClosed = errors.New("video job manager is closed")
+	// ErrJobTimeout is the error of a job which isn't over after the job timeout
+	ErrJobTimeout = errors.New("video recognition job timed out")
+	// ErrJobFailed is the error of a job which is over with a failed status
+	ErrJobFailed = errors.New("video recognition job failed")
+
+	// DefaultTerminalStatus is the status of a video result which means the recognition is over
+	DefaultTerminalStatus = []string{"finished", "done", "end", "closed", "close", "error", "failed"}
+	// DefaultFailedStatus is the terminal status which means the recognition is over without a valid result
+	DefaultFailedStatus = []string{"error", "failed"}
+)
+
+type (
+	// JobManager submits video async recognition jobs and tracks them until they are over,
+	// the result is received by callback or by polling QueryRecognitionResult
+	JobManager struct {
+		asyncHdler      *AsyncHandler
+		secretID        string
+		pollInterval    time.Duration
+		maxPollInterval time.Duration
+		maxConcurrency  int
+		jobTimeout      time.Duration
+		terminalStatus  []string
+		failedStatus    []string
+		store           tupujobstore.Store
+		onStoreError    func(error)
+
+		slotsOnce sync.Once
+		slots     chan struct{}
+		closeOnce sync.Once
+		closed    chan struct{}
+
+		mu   sync.Mutex
+		jobs map[string]*Job
+	}
+
+	// ManagerOptFunc is the optional setting of JobManager
+	ManagerOptFunc func(*JobManager)
+
+	// Job is a submitted video recognition task tracked by JobManager
+	Job struct {
+		// VideoID is the id of the video returned by TUPU
+		VideoID string
+		// VideoURL is the submitted video url
+		VideoURL string
+
+		manager    *JobManager
+		hasSlot    bool
+		done       chan struct{}
+		finishOnce sync.Once
+		// stateMu keeps the state saved by update from overwriting the state saved by finish
+		stateMu sync.Mutex
+
+		mu     sync.Mutex
+		latest *VideoResult
+		result *VideoResult
+		err    error
+	}
+)
+
+// NewJobManager is an initializer for a JobManager, all jobs are submitted with the secretID
+func NewJobManager(asyncHdler *AsyncHandler, secretID string, optFuncs ...ManagerOptFunc) (*JobManager, error) {
+	if tupuerror.PtrIsNil(asyncHdler) || tupuerror.StringIsEmpty(secretID) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	m := &JobManager{
+		asyncHdler:      asyncHdler,
+		secretID:        secretID,
+		pollInterval:    DefaultPollInterval,
+		maxPollInterval: DefaultMaxPollInterval,
+		jobTimeout:      DefaultJobTimeout,
+		terminalStatus:  DefaultTerminalStatus,
+		failedStatus:    DefaultFailedStatus,
+		closed:          make(chan struct{}),
+		jobs:            make(map[string]*Job),
+	}
+	for _, setConf := range optFuncs {
+		setConf(m)
+	}
+	if m.maxPollInterval < m.pollInterval {
+		m.maxPollInterval = m.pollInterval
+	}
+	return m, nil
+}
+
+// WithPollInterval sets the first polling interval, it is doubled after every query
+func WithPollInterval(interval time.Duration) ManagerOptFunc {
+	return func(m *JobManager) {
+		if interval > 0 {
+			m.pollInterval = interval
+		}
+	}
+}
+
+// WithMaxPollInterval sets the upper limit of the polling interval
+func WithMaxPollInterval(interval time.Duration) ManagerOptFunc {
+	return func(m *JobManager) {
+		if interval > 0 {
+			m.maxPollInterval = interval
+		}
+	}
+}
+
+// WithMaxConcurrency sets the number of running jobs, QueryRate is used if it isn't set
+func WithMaxConcurrency(maxConcurrency int) ManagerOptFunc {
+	return func(m *JobManager) {
+		m.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithTerminalStatus replaces DefaultTerminalStatus
+func WithTerminalStatus(statuses ...string) ManagerOptFunc {
+	return func(m *JobManager) {
+		if len(statuses) > 0 {
+			m.terminalStatus = statuses
+		}
+	}
+}
+
+// WithFailedStatus replaces DefaultFailedStatus, Wait returns ErrJobFailed for a job over with these statuses
+func WithFailedStatus(statuses ...string) ManagerOptFunc {
+	return func(m *JobManager) {
+		m.failedStatus = statuses
+	}
+}
+
+// WithJobTimeout sets the longest time a job is tracked, the job is closed on TUPU and
+// finished with ErrJobTimeout after that, 0 means no limit
+func WithJobTimeout(timeout time.Duration) ManagerOptFunc {
+	return func(m *JobManager) {
+		m.jobTimeout = timeout
+	}
+}
+
+// WithJobStore saves the jobs to store, so they can be recovered by Recover after restart
+func WithJobStore(store tupujobstore.Store) ManagerOptFunc {
+	return func(m *JobManager) {
+		m.store = store
+	}
+}
+
+// WithStoreErrorFunc sets the function called when a job can't be saved to the job store
+func WithStoreErrorFunc(onStoreError func(error)) ManagerOptFunc {
+	return func(m *JobManager) {
+		m.onStoreError = onStoreError
+	}
+}
+
+// Submit starts the recognition of videoURL, it blocks while the number of running jobs reaches the limit
+func (m *JobManager) Submit(ctx context.Context, videoURL, callbackURL string, optFuncs ...AsyncOptFunc) (*Job, error) {
+	m.initSlots()
+
+	select {
+	case m.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.closed:
+		return nil, ErrManagerClosed
+	}
+
+	r, err := checkVideoResult(m.asyncHdler.Perform(m.secretID, videoURL, callbackURL, optFuncs...))
+	if err == nil && len(r.VideoID) == 0 {
+		err = fmt.Errorf("no videoId in result: %s", r.Raw)
+	}
+	if err != nil {
+		<-m.slots
+		return nil, err
+	}
+
+	m.persist(r.VideoID, func(rec *tupujobstore.Record) {
+		rec.SecretID = m.secretID
+		rec.Source = videoURL
+		rec.State = tupujobstore.StateSubmitted
+	})
+	return m.track(r.VideoID, videoURL, true), nil
+}
+
+// Recover resumes tracking the unfinished jobs of the secretID saved in the job store,
+// the jobs submitted more than maxAge ago are closed instead, maxAge 0 means no limit
+func (m *JobManager) Recover(maxAge time.Duration) (jobs []*Job, err error) {
+	if m.store == nil {
+		return nil, fmt.Errorf("no job store, %s", tupuerror.GetCallerFuncName())
+	}
+
+	var records []*tupujobstore.Record
+	if records, err = tupujobstore.Pending(m.store, tupujobstore.KindVideoAsync); err != nil {
+		return nil, err
+	}
+
+	m.initSlots()
+	for _, rec := range records {
+		if rec.SecretID != m.secretID {
+			continue
+		}
+		if _, ok := m.Job(rec.ID); ok {
+			continue
+		}
+
+		if maxAge > 0 && time.Since(rec.CreatedAt) > maxAge {
+			// the orphaned job is closed, the error is kept and the rest jobs are recovered
+			if _, e := checkVideoResult(m.asyncHdler.CloseRecognitionTask(m.secretID, rec.ID)); e != nil {
+				err = e
+				continue
+			}
+			m.persist(rec.ID, func(rec *tupujobstore.Record) {
+				rec.State = tupujobstore.StateCancelled
+			})
+			continue
+		}
+
+		// a recovered job doesn't wait for the limit of running jobs
+		hasSlot := false
+		select {
+		case m.slots <- struct{}{}:
+			hasSlot = true
+		default:
+		}
+		jobs = append(jobs, m.track(rec.ID, rec.Source, hasSlot))
+	}
+	return jobs, err
+}
+
+// Job returns the running job of videoID
+func (m *JobManager) Job(videoID string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[videoID]
+	return job, ok
+}
+
+// Jobs returns all running jobs
+func (m *JobManager) Jobs() []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// HandleCallback updates the job with a verified callback result, callbacks of unknown videos are ignored
+func (m *JobManager) HandleCallback(result string) error {
+	r, err := ParseVideoResult(result)
+	if err != nil {
+		return err
+	}
+	if job, ok := m.Job(r.VideoID); ok {
+		job.update(r, true)
+	}
+	return nil
+}
+
+// NewCallbackReceiver creates a http.Handler which passes the callbacks to HandleCallback
+func (m *JobManager) NewCallbackReceiver() (*tupucallback.Receiver, error) {
+	return tupucallback.NewReceiver(m.HandleCallback)
+}
+
+// Close stops tracking all jobs, the running jobs are finished with ErrManagerClosed but not closed on TUPU
+func (m *JobManager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+	for _, job := range m.Jobs() {
+		job.finish(nil, ErrManagerClosed)
+	}
+}
+
+func (m *JobManager) initSlots() {
+	m.slotsOnce.Do(func() {
+		limit := m.maxConcurrency
+		if limit <= 0 {
+			limit = DefaultMaxConcurrency
+			if rate, err := m.queryRate(); err == nil && rate.Rate > 0 {
+				limit = rate.Rate
+			}
+		}
+		m.slots = make(chan struct{}, limit)
+	})
+}
+
+func (m *JobManager) queryRate() (*RateResult, error) {
+	result, statusCode, err := m.asyncHdler.QueryRate(m.secretID)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode > 299 {
+		return nil, fmt.Errorf("query rate failed, status code: %d", statusCode)
+	}
+	return ParseRateResult(result)
+}
+
+func (m *JobManager) track(videoID, videoURL string, hasSlot bool) *Job {
+	job := &Job{
+		VideoID:  videoID,
+		VideoURL: videoURL,
+		manager:  m,
+		hasSlot:  hasSlot,
+		done:     make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.jobs[videoID] = job
+	m.mu.Unlock()
+
+	go m.poll(job)
+	return job
+}
+
+func (m *JobManager) poll(job *Job) {
+	var (
+		interval = m.pollInterval
+		timer    = time.NewTimer(interval)
+		timeout  <-chan time.Time
+	)
+	defer timer.Stop()
+
+	if m.jobTimeout > 0 {
+		timeoutTimer := time.NewTimer(m.jobTimeout)
+		defer timeoutTimer.Stop()
+		timeout = timeoutTimer.C
+	}
+
+	for {
+		select {
+		case <-job.done:
+			return
+		case <-m.closed:
+			return
+		case <-timeout:
+			job.timeout()
+			return
+		case <-timer.C:
+		}
+
+		// a failed query is retried at the next interval
+		if r, err := checkVideoResult(m.asyncHdler.QueryRecognitionResult(m.secretID, job.VideoID)); err == nil {
+			job.update(r, false)
+		}
+
+		if interval *= 2; interval > m.maxPollInterval {
+			interval = m.maxPollInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+func (m *JobManager) isTerminal(r *VideoResult) bool {
+	return r.IsStatus(m.terminalStatus...) || r.IsStatus(m.failedStatus...)
+}
+
+func (m *JobManager) release(job *Job) {
+	m.mu.Lock()
+	if m.jobs[job.VideoID] == job {
+		delete(m.jobs, job.VideoID)
+	}
+	m.mu.Unlock()
+	if job.hasSlot {
+		<-m.slots
+	}
+}
+
+func (m *JobManager) persist(videoID string, fn func(rec *tupujobstore.Record)) {
+	if m.store == nil {
+		return
+	}
+	err := tupujobstore.Update(m.store, tupujobstore.KindVideoAsync, videoID, fn)
+	if err != nil && m.onStoreError != nil {
+		m.onStoreError(err)
+	}
+}
+
+// Wait blocks until the job is over or ctx is done, the job keeps running if ctx is done
+func (job *Job) Wait(ctx context.Context) (*VideoResult, error) {
+	select {
+	case <-job.done:
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		return job.result, job.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Done returns a channel which is closed when the job is over
+func (job *Job) Done() <-chan struct{} {
+	return job.done
+}
+
+// Latest returns the latest result received by callback or polling, it may be a partial result
+func (job *Job) Latest() *VideoResult {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return job.latest
+}
+
+// Cancel closes the recognition task on TUPU, Wait returns ErrJobCancelled after that
+func (job *Job) Cancel() error {
+	m := job.manager
+	if _, err := checkVideoResult(m.asyncHdler.CloseRecognitionTask(m.secretID, job.VideoID)); err != nil {
+		return err
+	}
+	job.finish(job.Latest(), ErrJobCancelled)
+	return nil
+}
+
+func (job *Job) update(r *VideoResult, fromCallback bool) {
+	job.mu.Lock()
+	job.latest = r
+	job.mu.Unlock()
+
+	if r.IsStatus(job.manager.failedStatus...) {
+		job.finish(r, fmt.Errorf("%w, status: %s, message: %s", ErrJobFailed, r.Status, r.Message))
+		return
+	}
+	if job.manager.isTerminal(r) {
+		job.finish(r, nil)
+		return
+	}
+
+	job.stateMu.Lock()
+	defer job.stateMu.Unlock()
+	select {
+	case <-job.done:
+		return
+	default:
+	}
+	job.manager.persist(job.VideoID, func(rec *tupujobstore.Record) {
+		rec.State = tupujobstore.StateRunning
+		rec.LastResult = r.Raw
+		if fromCallback {
+			rec.Callbacks++
+		}
+	})
+}
+
+func (job *Job) timeout() {
+	m := job.manager
+	err := ErrJobTimeout
+	if _, e := checkVideoResult(m.asyncHdler.CloseRecognitionTask(m.secretID, job.VideoID)); e != nil {
+		err = fmt.Errorf("%w, close task: %v", ErrJobTimeout, e)
+	}
+	job.finish(job.Latest(), err)
+}
+
+func (job *Job) finish(r *VideoResult, err error) {
+	job.finishOnce.Do(func() {
+		job.stateMu.Lock()
+		job.mu.Lock()
+		job.result = r
+		job.err = err
+		job.mu.Unlock()
+		close(job.done)
+
+		// the job is left unfinished in the store to be recovered when the manager is closed
+		if err != ErrManagerClosed {
+			job.manager.persist(job.VideoID, func(rec *tupujobstore.Record) {
+				switch {
+				case err == nil:
+					rec.State = tupujobstore.StateFinished
+				case err == ErrJobCancelled:
+					rec.State = tupujobstore.StateCancelled
+				default:
+					rec.State = tupujobstore.StateFailed
+				}
+				if r != nil {
+					rec.Result = r.Raw
+				}
+			})
+		}
+		job.stateMu.Unlock()
+		job.manager.release(job)
+	})
+}
+
+func checkVideoResult(result string, statusCode int, err error) (*VideoResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseVideoResult(result)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode > 299 || r.Code != 0 {
+		return r, fmt.Errorf("status code: %d, code: %d, message: %s", statusCode, r.Code, r.Message)
+	}
+	return r, nil
+}
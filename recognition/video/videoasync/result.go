@@ -0,0 +1,84 @@
+package videoasync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+type (
+	// VideoResult is a wrapper for video async result parsed from response or callback
+	VideoResult struct {
+		Code       int
+		Message    string
+		Timestamp  int64
+		Nonce      string
+		VideoID    string
+		Status     string
+		CustomInfo map[string]interface{}
+		// Tasks is the recognition result of every task, key is the task id
+		Tasks map[string]interface{}
+		// Others is the rest fields of the result
+		Others map[string]interface{}
+		// Raw is the original json string
+		Raw string
+	}
+
+	// RateResult is a wrapper for the result of QueryRate
+	RateResult struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		// Rate is the number of videos which can be recognized at the same time
+		Rate int `json:"rate"`
+	}
+)
+
+// ParseVideoResult is a helper to parse json string and create a VideoResult struct
+func ParseVideoResult(s string) (*VideoResult, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil, fmt.Errorf("invalid video result: %v", err)
+	}
+
+	r := &VideoResult{Raw: s}
+	common, err := tupumodel.ParseResultData(data, func(key string, val interface{}) bool {
+		switch key {
+		case "videoId":
+			r.VideoID, _ = val.(string)
+		case "status":
+			r.Status = fmt.Sprint(val)
+		case "customInfo":
+			r.CustomInfo, _ = val.(map[string]interface{})
+		default:
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid video result: %v", err)
+	}
+	r.Code, r.Message, r.Timestamp, r.Nonce = common.Code, common.Message, common.Timestamp, common.Nonce
+	r.Tasks, r.Others = common.Tasks, common.Others
+	return r, nil
+}
+
+// ParseRateResult is a helper to parse json string returned by QueryRate
+func ParseRateResult(s string) (*RateResult, error) {
+	r := new(RateResult)
+	if err := json.Unmarshal([]byte(s), r); err != nil {
+		return nil, fmt.Errorf("invalid rate result: %v", err)
+	}
+	return r, nil
+}
+
+// IsStatus reports whether the status of the result is one of statuses, case is ignored
+func (r *VideoResult) IsStatus(statuses ...string) bool {
+	for _, status := range statuses {
+		if strings.EqualFold(r.Status, status) {
+			return true
+		}
+	}
+	return false
+}
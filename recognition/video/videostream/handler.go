@@ -0,0 +1,165 @@
+package videostream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
+	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
+	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
+)
+
+const (
+	// VideoStreamURL is default video stream service address
+	VideoStreamURL = "http://api.open.tuputech.com/v3/recognition/video/stream/"
+
+	closePath  = "close/"
+	searchPath = "search/"
+)
+
+// VideoStreamHandler is a client-side helper to access TUPU video stream recognition service
+type VideoStreamHandler struct {
+	syncPool sync.Pool
+	hdler    *tupucontrol.Handler
+	rootURL  string
+}
+
+// NewVideoStreamHandler is an initializer for a VideoStreamHandler
+func NewVideoStreamHandler(privateKeyPath string) (*VideoStreamHandler, error) {
+	// verify the params
+	if tupuerror.StringIsEmpty(privateKeyPath) {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+
+	var (
+		err         error
+		vdstrmHdler = &VideoStreamHandler{rootURL: VideoStreamURL}
+	)
+
+	if vdstrmHdler.hdler, err = tupucontrol.NewHandlerWithURL(privateKeyPath, VideoStreamURL); err != nil {
+		return nil, err
+	}
+
+	vdstrmHdler.syncPool.New = func() interface{} {
+		return newVideoStream()
+	}
+
+	return vdstrmHdler, nil
+}
+
+// SetServerURL provide set request server URL attribute, the close and search apis are under the same URL
+func (vdstrmHdler *VideoStreamHandler) SetServerURL(url string) {
+	if tupuerror.StringIsEmpty(url) {
+		return
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	vdstrmHdler.rootURL = url
+	vdstrmHdler.hdler.SetServerURL(url)
+}
+
+// SetTimeout provide properties to set request ttl
+func (vdstrmHdler *VideoStreamHandler) SetTimeout(timeout int) {
+	vdstrmHdler.hdler.SetTimeout(timeout)
+}
+
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (vdstrmHdler *VideoStreamHandler) SetTransport(transport http.RoundTripper) {
+	vdstrmHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (vdstrmHdler *VideoStreamHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	vdstrmHdler.hdler.SetInterceptor(interceptor)
+}
+
+// SetVerifier provide replacing TUPU's public key to verify the signature of responses
+func (vdstrmHdler *VideoStreamHandler) SetVerifier(verifier tuputools.Verifier) {
+	vdstrmHdler.hdler.SetVerifier(verifier)
+}
+
+func (vdstrmHdler *VideoStreamHandler) recycleDataObj(videoStream *VideoStream) {
+	videoStream.ClearData()
+	vdstrmHdler.syncPool.Put(videoStream)
+}
+
+// StartStreamRecognition is the major method for initiating a video stream recognition request
+func (vdstrmHdler *VideoStreamHandler) StartStreamRecognition(secretID, streamUrl, callbackUrl string, optFuncs ...StreamOptFunc) (result string, statusCode int, err error) {
+
+	// step1. Invalid parameter check
+	if tupuerror.StringIsEmpty(secretID, streamUrl, callbackUrl) {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+
+	var (
+		videoStream   *VideoStream
+		requestParams []byte
+		paramsStr     string
+	)
+
+	videoStream = vdstrmHdler.syncPool.Get().(*VideoStream)
+	defer vdstrmHdler.recycleDataObj(videoStream)
+
+	// set optional params
+	videoStream.URL = streamUrl
+	videoStream.Callback = callbackUrl
+	videoStream.Interval = DefaultInterval
+
+	for _, setConf := range optFuncs {
+		setConf(videoStream)
+	}
+
+	// step2. serialize to JSON string
+	requestParams, _ = json.Marshal(videoStream)
+
+	paramsStr = `"videoStream":[` + string(requestParams) + `]`
+	if videoStream.tasks != nil {
+		taskStrSlice, _ := json.Marshal(videoStream.tasks)
+		paramsStr += `,"tasks":` + string(taskStrSlice)
+	}
+	// step3. transfer general api
+	return vdstrmHdler.hdler.RecognizeWithJSONAndURL(vdstrmHdler.rootURL, paramsStr, secretID)
+}
+
+// CloseRecognitionTask can close your video stream recognition task by requestId
+func (vdstrmHdler *VideoStreamHandler) CloseRecognitionTask(secretID, requestId string) (result string, statusCode int, err error) {
+	if tupuerror.StringIsEmpty(secretID, requestId) {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+	requestParams := `"videoStream":[{"requestId": "` + requestId + `"}]`
+	return vdstrmHdler.hdler.RecognizeWithJSONAndURL(vdstrmHdler.rootURL+closePath, requestParams, secretID)
+}
+
+// QueryStatus can query your video stream recognition status by requestId
+func (vdstrmHdler *VideoStreamHandler) QueryStatus(secretID, requestId string) (result string, statusCode int, err error) {
+	if tupuerror.StringIsEmpty(secretID, requestId) {
+		statusCode = 400
+		err = fmt.Errorf("[Params ERROR]: now func: %s\tcaller func: %s ", tupuerror.GetCurrentFuncName(), tupuerror.GetCallerFuncName())
+		return
+	}
+	requestParams := `"requestId": "` + requestId + `"`
+	return vdstrmHdler.hdler.RecognizeWithJSONAndURL(vdstrmHdler.rootURL+searchPath, requestParams, secretID)
+}
+
+// NewCallbackReceiver creates a http.Handler to receive the callback of video stream recognition
+func NewCallbackReceiver(onResult func(*CallbackResult) error) (*tupucallback.Receiver, error) {
+	if onResult == nil {
+		return nil, fmt.Errorf("%s, %s", tupuerror.ErrorParamsIsEmpty, tupuerror.GetCallerFuncName())
+	}
+	return tupucallback.NewReceiver(func(result string) error {
+		r, err := ParseCallbackResult(result)
+		if err != nil {
+			return err
+		}
+		return onResult(r)
+	})
+}
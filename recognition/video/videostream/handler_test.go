@@ -0,0 +1,281 @@
+package videostream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	tupucallback "github.com/tuputech/tupu-go-sdk/lib/callback"
+	tuputools "github.com/tuputech/tupu-go-sdk/lib/tools"
+)
+
+const testSecretID = "testsecret"
+
+// fakeServer plays TUPU video stream apis, the responses are signed with the same key as the requests
+type fakeServer struct {
+	*httptest.Server
+	key *tuputools.KeyInfo
+
+	mu       sync.Mutex
+	paths    []string
+	requests []map[string]interface{}
+	// reply returns the result json of a request path
+	reply func(path string) string
+}
+
+func newFakeServer(t *testing.T, reply func(path string) string) (*fakeServer, *VideoStreamHandler) {
+	privatePEM, _, err := tuputools.GenerateKeyPair(1024, tuputools.FormatPKCS1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "private.pem")
+	if err = ioutil.WriteFile(keyPath, privatePEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &fakeServer{reply: reply}
+	if srv.key, err = tuputools.InspectKey(privatePEM); err != nil {
+		t.Fatal(err)
+	}
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.serve))
+	t.Cleanup(srv.Close)
+
+	hdler, err := NewVideoStreamHandler(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdler.SetServerURL(srv.URL + "/v3/recognition/video/stream")
+	hdler.SetVerifier(srv.key.Verifier())
+	return srv, hdler
+}
+
+func (srv *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	var params map[string]interface{}
+	body, _ := ioutil.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	srv.mu.Lock()
+	srv.paths = append(srv.paths, r.URL.Path)
+	srv.requests = append(srv.requests, params)
+	srv.mu.Unlock()
+
+	result := srv.reply(r.URL.Path)
+	w.Write(srv.sign(result))
+}
+
+func (srv *fakeServer) sign(result string) []byte {
+	sig, _ := tuputools.SignString(srv.key.Signer(), result)
+	body, _ := json.Marshal(map[string]string{"json": result, "signature": sig})
+	return body
+}
+
+func (srv *fakeServer) last() (string, map[string]interface{}) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.paths) == 0 {
+		return "", nil
+	}
+	return srv.paths[len(srv.paths)-1], srv.requests[len(srv.requests)-1]
+}
+
+func streamReply(path string) string {
+	switch {
+	case strings.HasSuffix(path, "/close/"+testSecretID):
+		return `{"code":0,"message":"success","result":[{"requestId":"r1","status":"closed"}]}`
+	case strings.HasSuffix(path, "/search/"+testSecretID):
+		return `{"code":0,"message":"success","result":[{"requestId":"r1","status":"running"}]}`
+	default:
+		return `{"code":0,"message":"success","result":[{"requestId":"r1"}]}`
+	}
+}
+
+func TestStartStreamRecognition(t *testing.T) {
+	srv, hdler := newFakeServer(t, streamReply)
+
+	result, statusCode, err := hdler.StartStreamRecognition(testSecretID, "rtmp://live/1", "http://callback",
+		WithRoomID("room1"), WithAudio(true), WithTask("54bcfc6c329af61034f7c2fc"))
+	if err != nil || statusCode != http.StatusOK {
+		t.Fatalf("start: status code %d, err %v", statusCode, err)
+	}
+	r, err := ParseStreamResult(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.RequestID() != "r1" {
+		t.Errorf("requestId = %q, want r1", r.RequestID())
+	}
+
+	path, params := srv.last()
+	if want := "/v3/recognition/video/stream/" + testSecretID; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	streams, _ := params["videoStream"].([]interface{})
+	if len(streams) != 1 {
+		t.Fatalf("videoStream = %v, want 1 stream", params["videoStream"])
+	}
+	stream := streams[0].(map[string]interface{})
+	if stream["url"] != "rtmp://live/1" || stream["callback"] != "http://callback" || stream["roomId"] != "room1" {
+		t.Errorf("videoStream = %v", stream)
+	}
+	if stream["audio"] != true || stream["interval"] != float64(DefaultInterval) {
+		t.Errorf("audio and interval of videoStream = %v", stream)
+	}
+	if tasks := fmt.Sprint(params["tasks"]); tasks != "[54bcfc6c329af61034f7c2fc]" {
+		t.Errorf("tasks = %s", tasks)
+	}
+}
+
+func TestStreamOptions(t *testing.T) {
+	srv, hdler := newFakeServer(t, streamReply)
+
+	rules := map[string][]TaskCallbackRule{"54bcfc6c329af61034f7c2fc": {{Label: 1, Review: true}}}
+	_, _, err := hdler.StartStreamRecognition(testSecretID, "rtmp://live/1", "http://callback",
+		WithUserID("user1"), WithForumID("forum1"), WithInterval(5), WithCallbackRules(rules),
+		WithCustomInfo(map[string]interface{}{"k": "v"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, params := srv.last()
+	stream := params["videoStream"].([]interface{})[0].(map[string]interface{})
+	if stream["userId"] != "user1" || stream["forumId"] != "forum1" || stream["interval"] != float64(5) {
+		t.Errorf("videoStream = %v", stream)
+	}
+	if info, _ := stream["customInfo"].(map[string]interface{}); info["k"] != "v" {
+		t.Errorf("customInfo = %v", stream["customInfo"])
+	}
+	if _, ok := stream["callbackRules"].(map[string]interface{})["54bcfc6c329af61034f7c2fc"]; !ok {
+		t.Errorf("callbackRules = %v", stream["callbackRules"])
+	}
+	if _, ok := params["tasks"]; ok {
+		t.Errorf("tasks = %v, want no tasks", params["tasks"])
+	}
+
+	// the options of the previous request are not kept by the pooled VideoStream
+	if _, _, err = hdler.StartStreamRecognition(testSecretID, "rtmp://live/2", "http://callback"); err != nil {
+		t.Fatal(err)
+	}
+	_, params = srv.last()
+	stream = params["videoStream"].([]interface{})[0].(map[string]interface{})
+	for _, key := range []string{"userId", "forumId", "callbackRules", "customInfo"} {
+		if _, ok := stream[key]; ok {
+			t.Errorf("%s of reused VideoStream = %v, want empty", key, stream[key])
+		}
+	}
+}
+
+func TestCloseAndQueryStatus(t *testing.T) {
+	srv, hdler := newFakeServer(t, streamReply)
+
+	result, _, err := hdler.CloseRecognitionTask(testSecretID, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, params := srv.last()
+	if want := "/v3/recognition/video/stream/close/" + testSecretID; path != want {
+		t.Errorf("close path = %q, want %q", path, want)
+	}
+	if stream := params["videoStream"].([]interface{})[0].(map[string]interface{}); stream["requestId"] != "r1" {
+		t.Errorf("close videoStream = %v", stream)
+	}
+	if r, _ := ParseStreamResult(result); r == nil || r.Result[0].Status != "closed" {
+		t.Errorf("close result = %s", result)
+	}
+
+	if result, _, err = hdler.QueryStatus(testSecretID, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	path, params = srv.last()
+	if want := "/v3/recognition/video/stream/search/" + testSecretID; path != want {
+		t.Errorf("search path = %q, want %q", path, want)
+	}
+	if params["requestId"] != "r1" {
+		t.Errorf("search requestId = %v", params["requestId"])
+	}
+	if r, _ := ParseStreamResult(result); r == nil || r.Result[0].Status != "running" {
+		t.Errorf("search result = %s", result)
+	}
+
+	if _, statusCode, err := hdler.CloseRecognitionTask(testSecretID, ""); err == nil || statusCode != 400 {
+		t.Errorf("close without requestId: status code %d, err %v", statusCode, err)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	srv, hdler := newFakeServer(t, streamReply)
+
+	if _, _, err := hdler.QueryStatus(testSecretID, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	// the request is signed with the private key of the handler
+	_, params := srv.last()
+	message := tuputools.SignatureMessage(testSecretID, fmt.Sprint(params["timestamp"]), fmt.Sprint(params["nonce"]))
+	if err := tuputools.VerifyString(srv.key.Verifier(), message, fmt.Sprint(params["signature"])); err != nil {
+		t.Errorf("request signature: %v", err)
+	}
+
+	// a response signed with another key is rejected
+	otherPEM, _, err := tuputools.GenerateKeyPair(1024, tuputools.FormatPKCS1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := tuputools.InspectKey(otherPEM)
+	hdler.SetVerifier(other.Verifier())
+	if _, _, err = hdler.QueryStatus(testSecretID, "r1"); err == nil {
+		t.Error("response signed with another key is accepted")
+	}
+}
+
+func TestCallbackReceiver(t *testing.T) {
+	srv, _ := newFakeServer(t, streamReply)
+
+	var received *CallbackResult
+	rcv, err := NewCallbackReceiver(func(r *CallbackResult) error {
+		received = r
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcv.SetVerifier(srv.key.Verifier())
+
+	post := func(body []byte) int {
+		w := httptest.NewRecorder()
+		rcv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(string(body))))
+		return w.Code
+	}
+
+	result := `{"code":0,"requestId":"r1","roomId":"room1","status":"running","timestamp":1600000000,` +
+		`"54bcfc6c329af61034f7c2fc":{"label":1,"review":false}}`
+	if code := post(srv.sign(result)); code != http.StatusOK {
+		t.Fatalf("signed callback: status code %d", code)
+	}
+	if received == nil || received.RequestID != "r1" || received.RoomID != "room1" || received.Timestamp != 1600000000 {
+		t.Fatalf("callback result = %+v", received)
+	}
+	if _, ok := received.Tasks["54bcfc6c329af61034f7c2fc"]; !ok {
+		t.Errorf("tasks of callback = %v", received.Tasks)
+	}
+
+	// a tampered callback is rejected before onResult
+	received = nil
+	tampered := strings.Replace(string(srv.sign(result)), "room1", "room2", 1)
+	if code := post([]byte(tampered)); code != http.StatusBadRequest || received != nil {
+		t.Errorf("tampered callback: status code %d, result %+v", code, received)
+	}
+	if code := post([]byte(result)); code != http.StatusBadRequest {
+		t.Errorf("unsigned callback: status code %d", code)
+	}
+	if _, err = rcv.Parse([]byte(result)); err != tupucallback.ErrNoResult {
+		t.Errorf("parse unsigned callback: %v, want %v", err, tupucallback.ErrNoResult)
+	}
+}
@@ -0,0 +1,102 @@
+package videostream
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
+)
+
+type (
+	// StreamResult is a wrapper for the result of video stream apis
+	StreamResult struct {
+		Code      int            `json:"code"`
+		Message   string         `json:"message"`
+		Timestamp int64          `json:"timestamp"`
+		Nonce     string         `json:"nonce"`
+		Result    []StreamStatus `json:"result"`
+	}
+
+	// StreamStatus is the state of a video stream in StreamResult
+	StreamStatus struct {
+		RequestID string `json:"requestId"`
+		URL       string `json:"url,omitempty"`
+		// Status is the recognition status of the stream
+		Status interface{} `json:"status,omitempty"`
+		// Code is the error code of the stream, 0 means success
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+
+	// CallbackResult is a wrapper for the recognition result posted to the callback url
+	CallbackResult struct {
+		Code       int
+		Message    string
+		Timestamp  int64
+		Nonce      string
+		RequestID  string
+		RoomID     string
+		UserID     string
+		ForumID    string
+		Status     string
+		CustomInfo map[string]interface{}
+		// Tasks is the recognition result of every task, key is the task id
+		Tasks map[string]interface{}
+		// Others is the rest fields of the result
+		Others map[string]interface{}
+		// Raw is the original json string
+		Raw string
+	}
+)
+
+// ParseStreamResult is a helper to parse json string returned by video stream apis
+func ParseStreamResult(s string) (*StreamResult, error) {
+	r := new(StreamResult)
+	if err := json.Unmarshal([]byte(s), r); err != nil {
+		return nil, fmt.Errorf("invalid video stream result: %v", err)
+	}
+	return r, nil
+}
+
+// RequestID returns the requestId of the first stream in the result
+func (r *StreamResult) RequestID() string {
+	if len(r.Result) == 0 {
+		return ""
+	}
+	return r.Result[0].RequestID
+}
+
+// ParseCallbackResult is a helper to parse json string posted to the callback url
+func ParseCallbackResult(s string) (*CallbackResult, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		return nil, fmt.Errorf("invalid video stream callback: %v", err)
+	}
+
+	r := &CallbackResult{Raw: s}
+	common, err := tupumodel.ParseResultData(data, func(key string, val interface{}) bool {
+		switch key {
+		case "requestId":
+			r.RequestID, _ = val.(string)
+		case "roomId":
+			r.RoomID = fmt.Sprint(val)
+		case "userId":
+			r.UserID = fmt.Sprint(val)
+		case "forumId":
+			r.ForumID = fmt.Sprint(val)
+		case "status":
+			r.Status = fmt.Sprint(val)
+		case "customInfo":
+			r.CustomInfo, _ = val.(map[string]interface{})
+		default:
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid video stream callback: %v", err)
+	}
+	r.Code, r.Message, r.Timestamp, r.Nonce = common.Code, common.Message, common.Timestamp, common.Nonce
+	r.Tasks, r.Others = common.Tasks, common.Others
+	return r, nil
+}
@@ -0,0 +1,120 @@
+// Package videostream provide interface of TUPU video stream recognition
+package videostream
+
+import (
+	"github.com/tuputech/tupu-go-sdk/recognition/video/videoasync"
+)
+
+type (
+	// TaskCallbackRule is the callback rule of a task, same as the rule of video async recognition
+	TaskCallbackRule = videoasync.TaskCallbackRule
+
+	// VideoStream descript the live video stream to recognize
+	VideoStream struct {
+		// URL is your video stream url
+		URL string `json:"url,omitempty"`
+		// Callback is your receive the recognition result url
+		Callback string `json:"callback,omitempty"`
+		// RoomID is customer params
+		RoomID string `json:"roomId,omitempty"`
+		// UserID is customer params
+		UserID string `json:"userId,omitempty"`
+		// ForumID is customer params
+		ForumID string `json:"forumId,omitempty"`
+		// Interval is the seconds between two recognized frames
+		Interval uint8 `json:"interval,omitempty"`
+		// Audio means the audio of the stream is recognized too
+		Audio bool `json:"audio,omitempty"`
+		// CallbackRules is the callback rules of every task, key is the task id
+		CallbackRules map[string][]TaskCallbackRule `json:"callbackRules,omitempty"`
+		// CustomInfo is returned with the result as it is
+		CustomInfo map[string]interface{} `json:"customInfo,omitempty"`
+		tasks      []string
+	}
+
+	// StreamOptFunc is the optional setting of VideoStream
+	StreamOptFunc func(*VideoStream)
+)
+
+const (
+	// DefaultInterval is the default seconds between two recognized frames
+	DefaultInterval = 1
+)
+
+func newVideoStream(optFuncs ...StreamOptFunc) *VideoStream {
+	var (
+		vdstrm = new(VideoStream)
+	)
+	for _, setConf := range optFuncs {
+		setConf(vdstrm)
+	}
+	return vdstrm
+}
+
+// ClearData is an helper to reset VideoStream
+func (vdstrm *VideoStream) ClearData() {
+	vdstrm.tasks = nil
+	vdstrm.URL = ""
+	vdstrm.Callback = ""
+	vdstrm.RoomID = ""
+	vdstrm.UserID = ""
+	vdstrm.ForumID = ""
+	vdstrm.Interval = 0
+	vdstrm.Audio = false
+	vdstrm.CallbackRules = nil
+	vdstrm.CustomInfo = nil
+}
+
+func (vdstrm *VideoStream) InitOptionParams(optFuncs ...StreamOptFunc) {
+	for _, opt := range optFuncs {
+		opt(vdstrm)
+	}
+}
+
+func WithRoomID(roomId string) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.RoomID = roomId
+	}
+}
+
+func WithUserID(userId string) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.UserID = userId
+	}
+}
+
+func WithForumID(forumId string) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.ForumID = forumId
+	}
+}
+
+func WithInterval(interval uint8) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.Interval = interval
+	}
+}
+
+func WithAudio(audio bool) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.Audio = audio
+	}
+}
+
+func WithCallbackRules(callbackRules map[string][]TaskCallbackRule) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.CallbackRules = callbackRules
+	}
+}
+
+func WithCustomInfo(customInfo map[string]interface{}) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.CustomInfo = customInfo
+	}
+}
+
+func WithTask(tasks ...string) StreamOptFunc {
+	return func(vdstrm *VideoStream) {
+		vdstrm.tasks = tasks
+	}
+}
@@ -2,10 +2,14 @@ package videosync
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
+	"time"
 
+	tupucache "github.com/tuputech/tupu-go-sdk/lib/cache"
 	tupucontrol "github.com/tuputech/tupu-go-sdk/lib/controller"
 	tupuerror "github.com/tuputech/tupu-go-sdk/lib/errorlib"
+	tupumediatype "github.com/tuputech/tupu-go-sdk/lib/mediatype"
 	tupumodel "github.com/tuputech/tupu-go-sdk/lib/model"
 )
 
@@ -16,8 +20,9 @@ const (
 
 // SyncHandler is a client-side helper to access TUPU video recognition service
 type SyncHandler struct {
-	syncPool sync.Pool
-	hdler    *tupucontrol.Handler
+	syncPool  sync.Pool
+	hdler     *tupucontrol.Handler
+	mediaRule *tupumediatype.Rule
 }
 
 // NewSyncHandler is an initializer for a VideoHandler
@@ -36,6 +41,7 @@ func NewSyncHandler(privateKeyPath string) (*SyncHandler, error) {
 		return nil, err
 	}
 
+	syncHdler.mediaRule = tupumediatype.VideoSyncRule
 	syncHdler.syncPool.New = func() interface{} {
 		return newVidoSync()
 	}
@@ -48,6 +54,17 @@ func (syncHdler *SyncHandler) SetServerURL(url string) {
 	syncHdler.hdler.SetServerURL(url)
 }
 
+// SetMediaRule provide setting the formats and size limit checked before uploading local and
+// binary files, tupumediatype.VideoSyncRule by default. nil disables the check
+func (syncHdler *SyncHandler) SetMediaRule(rule *tupumediatype.Rule) {
+	syncHdler.mediaRule = rule
+}
+
+// SetCache provide setting the cache of recognition results keyed by content hash, nil disables it
+func (syncHdler *SyncHandler) SetCache(cache tupucache.Cache, ttl time.Duration) {
+	syncHdler.hdler.SetCache(cache, ttl)
+}
+
 // PerformWithBinary is the major method for initiating a video recognition request, Params binaryData key is fileName(include filetype, example "1.flv"), value is binary data
 func (syncHdler *SyncHandler) PerformWithBinary(secretID string, binaryData map[string][]byte, optFuncs ...SyncOptFunc) (result string, statusCode int, err error) {
 
@@ -72,6 +89,11 @@ func (syncHdler *SyncHandler) PerformWithBinary(secretID string, binaryData map[
 		videoSync.InitOptionParams(optFuncs...)
 		dataInfoSlice = append(dataInfoSlice, videoSync.dataInfo)
 	}
+	if err = syncHdler.validate(dataInfoSlice); err != nil {
+		statusCode = 400
+		return
+	}
+
 	// Do request
 	return syncHdler.hdler.Recognize(secretID, dataInfoSlice, videoSync.tasks)
 }
@@ -132,17 +154,26 @@ func (syncHdler *SyncHandler) PerformWithPath(secretID string, speechPaths []str
 		dataInfoSlice = append(dataInfoSlice, videoSync.dataInfo)
 	}
 
+	if err = syncHdler.validate(dataInfoSlice); err != nil {
+		statusCode = 400
+		return
+	}
+
 	// Do request
 	return syncHdler.hdler.Recognize(secretID, dataInfoSlice, videoSync.tasks)
 }
 
-func illegalSpeechFile(fileExtend string) bool {
-	switch fileExtend {
-	case ".mkv", ".mp4", ".wmv", ".rmvb", ".flv", ".3gp", ".ts", ".mov", ".gif", ".m3u8", ".mpg/mpeg", ".mxf":
-		return false
-	default:
-		return true
+// validate checks the formats and sizes of the local and binary files before uploading
+func (syncHdler *SyncHandler) validate(dataInfoSlice []*tupumodel.DataInfo) error {
+	if syncHdler.mediaRule == nil {
+		return nil
+	}
+	for _, dataInfo := range dataInfoSlice {
+		if _, err := syncHdler.mediaRule.ValidateDataInfo(dataInfo); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (syncHdler *SyncHandler) recycleDataObj(videoSync *VideoSync) {
@@ -154,3 +185,13 @@ func (syncHdler *SyncHandler) recycleDataObj(videoSync *VideoSync) {
 func (syncHdler *SyncHandler) SetTimeout(timeout int) {
 	syncHdler.hdler.SetTimeout(timeout)
 }
+
+// SetTransport provide setting the transport of the http client, such as a cassette.Recorder
+func (syncHdler *SyncHandler) SetTransport(transport http.RoundTripper) {
+	syncHdler.hdler.SetTransport(transport)
+}
+
+// SetInterceptor provide setting the function called after every request, such as audit.Log.Interceptor
+func (syncHdler *SyncHandler) SetInterceptor(interceptor tupucontrol.Interceptor) {
+	syncHdler.hdler.SetInterceptor(interceptor)
+}